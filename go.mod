@@ -1,6 +1,6 @@
 module github.com/foxcapades/swrv
 
-go 1.21
+go 1.23
 
 require (
 	github.com/gorilla/mux v1.8.0
@@ -0,0 +1,407 @@
+package swrv
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLog records an entry for every request handled by a Server.
+//
+// An AccessLog may be attached to a Server using Server.WithAccessLog.
+//
+// Implementations must be safe for concurrent use.
+type AccessLog interface {
+	// Log records the given entry.
+	Log(entry AccessLogEntry)
+}
+
+// AccessLogFunc is a function that implements the AccessLog interface.
+type AccessLogFunc func(entry AccessLogEntry)
+
+func (f AccessLogFunc) Log(entry AccessLogEntry) {
+	f(entry)
+}
+
+// AccessLogEntry describes a single request handled by a Server, and the
+// response that was returned for it.
+type AccessLogEntry struct {
+	// Time is the time at which the request was received.
+	Time time.Time
+
+	// Duration is the time taken to handle the request and write the response.
+	Duration time.Duration
+
+	// ClientIP is the IP address of the client that sent the request, taken
+	// from the request's remote address.
+	ClientIP string
+
+	// User is the username sent with the request using HTTP basic auth, if any.
+	User string
+
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Path is the request URI as sent by the client, including any query
+	// string.
+	Path string
+
+	// Protocol is the HTTP protocol version of the request, for example
+	// "HTTP/1.1".
+	Protocol string
+
+	// Route is the path template of the controller that handled the request,
+	// for example "/users/{id}".
+	//
+	// Route will be empty for requests that did not match any controller.
+	Route string
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Bytes is the number of response body bytes written.
+	Bytes int64
+
+	// UserAgent is the value of the request's User-Agent header.
+	UserAgent string
+
+	// Referer is the value of the request's Referer header.
+	Referer string
+
+	// RequestID is the request's ID, as returned by Request.RequestID.
+	RequestID string
+}
+
+// AccessLogFormat defines the line format used by an AccessLog created with
+// NewAccessLogWriter.
+type AccessLogFormat uint8
+
+const (
+	// AccessLogCommon formats entries using the Apache Common Log Format.
+	//
+	//   127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326
+	//
+	// As with Apache httpd, quotes and backslashes in request derived values are
+	// escaped with a backslash, and non-printable bytes are written as "\xNN".
+	AccessLogCommon AccessLogFormat = iota
+
+	// AccessLogCombined formats entries using the Apache Combined Log Format,
+	// which is the Common Log Format followed by the request's Referer and
+	// User-Agent headers.
+	AccessLogCombined
+
+	// AccessLogJSON formats entries as single line JSON objects containing every
+	// field of the AccessLogEntry.
+	AccessLogJSON
+)
+
+// NewAccessLogWriter returns an AccessLog that writes entries to the given
+// io.Writer, one line per entry, in the given format.
+//
+// Each line is written to the io.Writer with a single call to Write.
+func NewAccessLogWriter(format AccessLogFormat, writer io.Writer) AccessLog {
+	return &writerAccessLog{format: format, writer: writer}
+}
+
+type writerAccessLog struct {
+	lock   sync.Mutex
+	format AccessLogFormat
+	writer io.Writer
+}
+
+func (w *writerAccessLog) Log(entry AccessLogEntry) {
+	var line []byte
+
+	switch w.format {
+	case AccessLogCombined:
+		line = appendCombinedLog(nil, entry)
+	case AccessLogJSON:
+		line = appendJSONLog(nil, entry)
+	default:
+		line = appendCommonLog(nil, entry)
+	}
+
+	line = append(line, '\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, _ = w.writer.Write(line)
+}
+
+// NewAccessLogLogger returns an AccessLog that logs each entry as an info level
+// message through the given Logger, with each field of the entry included as a
+// key/value pair.
+//
+// If the given Logger is nil, entries will be logged through the Logger
+// configured on the Server the AccessLog is attached to.
+func NewAccessLogLogger(logger Logger) AccessLog {
+	return loggerAccessLog{logger}
+}
+
+type loggerAccessLog struct {
+	logger Logger
+}
+
+func (l loggerAccessLog) Log(entry AccessLogEntry) {
+	l.logger.Info("request completed",
+		"client-ip", entry.ClientIP,
+		"method", entry.Method,
+		"path", entry.Path,
+		"protocol", entry.Protocol,
+		"route", entry.Route,
+		"status", entry.Status,
+		"bytes", entry.Bytes,
+		"duration", entry.Duration,
+		"user-agent", entry.UserAgent,
+		"referer", entry.Referer,
+		"request-id", entry.RequestID,
+	)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func appendCommonLog(line []byte, entry AccessLogEntry) []byte {
+	line = append(line, orDash(entry.ClientIP)...)
+	line = append(line, " - "...)
+	line = appendLogEscaped(line, orDash(entry.User))
+	line = append(line, " ["...)
+	line = entry.Time.AppendFormat(line, "02/Jan/2006:15:04:05 -0700")
+	line = append(line, "] \""...)
+	line = appendLogEscaped(line, entry.Method)
+	line = append(line, ' ')
+	line = appendLogEscaped(line, entry.Path)
+	line = append(line, ' ')
+	line = appendLogEscaped(line, entry.Protocol)
+	line = append(line, "\" "...)
+	line = strconv.AppendInt(line, int64(entry.Status), 10)
+	line = append(line, ' ')
+
+	if entry.Bytes > 0 {
+		line = strconv.AppendInt(line, entry.Bytes, 10)
+	} else {
+		line = append(line, '-')
+	}
+
+	return line
+}
+
+func appendCombinedLog(line []byte, entry AccessLogEntry) []byte {
+	line = appendCommonLog(line, entry)
+	line = append(line, " \""...)
+	line = appendLogEscaped(line, orDash(entry.Referer))
+	line = append(line, "\" \""...)
+	line = appendLogEscaped(line, orDash(entry.UserAgent))
+	line = append(line, '"')
+
+	return line
+}
+
+func appendJSONLog(line []byte, entry AccessLogEntry) []byte {
+	out, _ := json.Marshal(struct {
+		Time       string  `json:"time"`
+		ClientIP   string  `json:"client-ip"`
+		User       string  `json:"user,omitempty"`
+		Method     string  `json:"method"`
+		Path       string  `json:"path"`
+		Protocol   string  `json:"protocol"`
+		Route      string  `json:"route,omitempty"`
+		Status     int     `json:"status"`
+		Bytes      int64   `json:"bytes"`
+		DurationMS float64 `json:"duration-ms"`
+		UserAgent  string  `json:"user-agent,omitempty"`
+		Referer    string  `json:"referer,omitempty"`
+		RequestID  string  `json:"request-id"`
+	}{
+		Time:       entry.Time.Format(time.RFC3339Nano),
+		ClientIP:   entry.ClientIP,
+		User:       entry.User,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		Protocol:   entry.Protocol,
+		Route:      entry.Route,
+		Status:     entry.Status,
+		Bytes:      entry.Bytes,
+		DurationMS: float64(entry.Duration) / float64(time.Millisecond),
+		UserAgent:  entry.UserAgent,
+		Referer:    entry.Referer,
+		RequestID:  entry.RequestID,
+	})
+
+	return append(line, out...)
+}
+
+// appendLogEscaped appends the given request derived value to the given log
+// line, escaping quotes, backslashes, and non-printable bytes the way Apache
+// httpd does, so that a value cannot end its quoted field or forge log lines.
+func appendLogEscaped(line []byte, value string) []byte {
+	const hex = "0123456789abcdef"
+
+	for i := 0; i < len(value); i++ {
+		switch b := value[i]; {
+		case b == '"' || b == '\\':
+			line = append(line, '\\', b)
+		case b == '\b':
+			line = append(line, `\b`...)
+		case b == '\n':
+			line = append(line, `\n`...)
+		case b == '\r':
+			line = append(line, `\r`...)
+		case b == '\t':
+			line = append(line, `\t`...)
+		case b == '\v':
+			line = append(line, `\v`...)
+		case b < 0x20 || b >= 0x7f:
+			line = append(line, '\\', 'x', hex[b>>4], hex[b&0xf])
+		default:
+			line = append(line, b)
+		}
+	}
+
+	return line
+}
+
+func orDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+
+	return value
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// accessLogHandler wraps a Server's handler to record an AccessLogEntry for
+// every request.
+type accessLogHandler struct {
+	handler http.Handler
+	log     AccessLog
+}
+
+// accessLogStateKey is the context key under which the accessLogState for a
+// request is stored.
+type accessLogStateKey struct{}
+
+// accessLogState holds details about a request that are only known to the
+// controller handling it.
+type accessLogState struct {
+	route string
+}
+
+// recordAccessLogRoute records the given route template as the route that
+// matched the request with the given context, if the request is being access
+// logged.
+func recordAccessLogRoute(ctx context.Context, route string) {
+	if state, ok := ctx.Value(accessLogStateKey{}).(*accessLogState); ok && len(route) > 0 {
+		state.route = route
+	}
+}
+
+func (a accessLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	state := new(accessLogState)
+	id := requestID(r)
+
+	ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+	ctx = context.WithValue(ctx, accessLogStateKey{}, state)
+
+	writer := &countingWriter{ResponseWriter: w}
+
+	defer func() {
+		user, _, _ := r.BasicAuth()
+
+		path := r.RequestURI
+		if len(path) == 0 {
+			path = r.URL.RequestURI()
+		}
+
+		if writer.status == 0 {
+			writer.status = http.StatusOK
+		}
+
+		a.log.Log(AccessLogEntry{
+			Time:      start,
+			Duration:  time.Since(start),
+			ClientIP:  clientIP(r),
+			User:      user,
+			Method:    r.Method,
+			Path:      path,
+			Protocol:  r.Proto,
+			Route:     state.route,
+			Status:    writer.status,
+			Bytes:     writer.bytes,
+			UserAgent: r.UserAgent(),
+			Referer:   r.Referer(),
+			RequestID: id,
+		})
+	}()
+
+	a.handler.ServeHTTP(writer, r.WithContext(ctx))
+}
+
+// clientIP returns the IP address portion of the given request's remote
+// address.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// countingWriter wraps an http.ResponseWriter to record the response status
+// and the number of body bytes written.
+type countingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (c *countingWriter) WriteHeader(code int) {
+	if c.status == 0 || c.status < 200 {
+		c.status = code
+	}
+
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	n, err := c.ResponseWriter.Write(b)
+	c.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom preserves the io.ReaderFrom optimization of the wrapped writer, if
+// it has one.
+func (c *countingWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	var n int64
+	var err error
+
+	if rf, ok := c.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(reader)
+	} else {
+		n, err = io.Copy(writerOnly{c.ResponseWriter}, reader)
+	}
+
+	c.bytes += n
+	return n, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for use by
+// http.ResponseController.
+func (c *countingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
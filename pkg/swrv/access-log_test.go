@@ -0,0 +1,39 @@
+package swrv_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+func TestAccessLogEscaping(t *testing.T) {
+	entry := swrv.AccessLogEntry{
+		Time:      time.Date(2000, 10, 10, 13, 55, 36, 0, time.UTC),
+		ClientIP:  "127.0.0.1",
+		User:      "fr ank",
+		Method:    "GET",
+		Path:      "/a\" 200 1 \"-\" \"-\"\n127.0.0.1 - - [forged] \"GET /\\",
+		Protocol:  "HTTP/1.1",
+		Status:    200,
+		Bytes:     12,
+		Referer:   "https://example.com/\"\x01",
+		UserAgent: "agent\té",
+	}
+
+	var out bytes.Buffer
+	swrv.NewAccessLogWriter(swrv.AccessLogCombined, &out).Log(entry)
+
+	expected := `127.0.0.1 - fr ank [10/Oct/2000:13:55:36 +0000] ` +
+		`"GET /a\" 200 1 \"-\" \"-\"\n127.0.0.1 - - [forged] \"GET /\\ HTTP/1.1" 200 12 ` +
+		`"https://example.com/\"\x01" "agent\t\xc3\xa9"` + "\n"
+
+	if out.String() != expected {
+		t.Errorf("unexpected log line\nexpected: %s\ngot:      %s", expected, out.String())
+	}
+
+	if bytes.Count(out.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("expected a single log line, got %q", out.String())
+	}
+}
@@ -0,0 +1,394 @@
+package swrv
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultCompressionThreshold is the minimum response body size, in bytes, that
+// will be compressed by a Compression policy without a configured threshold.
+const DefaultCompressionThreshold = 1024
+
+// NewCompression returns a new response Compression policy which may be
+// attached to a Server using Server.WithCompression.
+//
+// By default, the returned policy compresses response bodies of at least
+// DefaultCompressionThreshold bytes with a text, JSON, XML, JavaScript, or SVG
+// content type, using gzip or deflate.
+func NewCompression() Compression {
+	return &compression{threshold: DefaultCompressionThreshold}
+}
+
+// Compression defines how a Server compresses response bodies.
+//
+// When a Compression policy is attached to a Server, the Server will negotiate
+// a content coding with each client using the Accept-Encoding request header,
+// and will compress eligible serialized and streamed response bodies using the
+// selected coding.
+//
+// Responses that already have a Content-Encoding, partial content responses,
+// and responses with content types that are already compressed, such as
+// ContentTypeApplicationZip, ContentTypeApplicationGZip, and most image, audio,
+// and video types, are never compressed.
+type Compression interface {
+	// WithThreshold sets the minimum response body size, in bytes, that will be
+	// compressed.
+	//
+	// Streamed bodies that are flushed before reaching the threshold are
+	// compressed regardless of their size.
+	//
+	// If unset, DefaultCompressionThreshold is used.
+	WithThreshold(bytes int) Compression
+
+	// WithContentTypes sets the response content types that will be compressed,
+	// replacing the default set.
+	//
+	// Content types may be given exactly, for example "application/json", or as
+	// a wildcard subtype, for example "text/*".  Content types ending in "+json"
+	// or "+xml" are matched by "application/json" and "application/xml"
+	// respectively.
+	WithContentTypes(types ...string) Compression
+
+	// WithEncoders sets the ContentEncoder instances that will be used to
+	// compress responses, in order of preference, replacing the default gzip and
+	// deflate encoders.
+	WithEncoders(encoders ...ContentEncoder) Compression
+
+	settings() *compression
+}
+
+var defaultCompressibleTypes = []string{
+	"text/*",
+	ContentTypeApplicationJSON,
+	ContentTypeApplicationXML,
+	"application/javascript",
+	ContentTypeApplicationNDJSON,
+	ContentTypeImageSVG,
+}
+
+type compression struct {
+	threshold int
+	types     []string
+	encoders  []ContentEncoder
+	encodings []string
+}
+
+func (c *compression) WithThreshold(bytes int) Compression {
+	c.threshold = bytes
+	return c
+}
+
+func (c *compression) WithContentTypes(types ...string) Compression {
+	c.types = append(c.types, types...)
+	return c
+}
+
+func (c *compression) WithEncoders(encoders ...ContentEncoder) Compression {
+	c.encoders = append(c.encoders, encoders...)
+	return c
+}
+
+func (c *compression) settings() *compression {
+	out := *c
+
+	if len(out.types) == 0 {
+		out.types = defaultCompressibleTypes
+	}
+
+	if len(out.encoders) == 0 {
+		out.encoders = []ContentEncoder{NewGzipEncoder(gzip.DefaultCompression), NewDeflateEncoder(gzip.DefaultCompression)}
+	}
+
+	out.encodings = make([]string, len(out.encoders))
+	for i, encoder := range out.encoders {
+		out.encodings[i] = encoder.Encoding()
+	}
+
+	return &out
+}
+
+// compressible tests whether responses with the given content type should be
+// compressed.
+func (c *compression) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if alreadyCompressed(mediaType) {
+		return false
+	}
+
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	// Treat structured syntax suffixes as their base type, e.g.
+	// "application/problem+json" as "application/json".
+	suffixed := ""
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		suffixed = typ + "/" + subtype[i+1:]
+	}
+
+	for _, allowed := range c.types {
+		if allowedType, allowedSubtype, _ := strings.Cut(allowed, "/"); allowedSubtype == "*" {
+			if allowedType == typ {
+				return true
+			}
+		} else if strings.EqualFold(allowed, mediaType) || strings.EqualFold(allowed, suffixed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *compression) encoder(encoding string) ContentEncoder {
+	for _, encoder := range c.encoders {
+		if encoder.Encoding() == encoding {
+			return encoder
+		}
+	}
+
+	return nil
+}
+
+// alreadyCompressed tests whether the given media type is a format that is
+// already compressed, and would not benefit from further compression.
+func alreadyCompressed(mediaType string) bool {
+	switch mediaType {
+	case ContentTypeApplicationZip, ContentTypeApplicationGZip, "application/x-gzip",
+		"application/zstd", "application/x-bzip2", "application/x-7z-compressed",
+		"font/woff", "font/woff2":
+		return true
+	case ContentTypeImageSVG:
+		return false
+	}
+
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	return typ == "image" || typ == "audio" || typ == "video"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// compressionHandler wraps a Server's handler to compress response bodies.
+type compressionHandler struct {
+	handler http.Handler
+	policy  *compression
+}
+
+func (c compressionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writer := &compressWriter{
+		ResponseWriter: w,
+		policy:         c.policy,
+		encoding:       negotiateEncoding(r.Header.Values(HeaderAcceptEncoding), c.policy.encodings),
+		ifNoneMatch:    r.Header.Values(HeaderIfNoneMatch),
+		head:           r.Method == http.MethodHead,
+	}
+
+	// Make the codings appended to entity tags known to the conditional request
+	// handling, so that clients revalidating a compressed response match the
+	// entity tag of the uncompressed representation.
+	r = r.WithContext(context.WithValue(r.Context(), etagCodingsKey{}, c.policy.encodings))
+
+	c.handler.ServeHTTP(writer, r)
+
+	if err := writer.close(); err != nil {
+		// The response has already been sent, all that can be done is to abort
+		// the connection so the client sees an incomplete response.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// compressWriter wraps an http.ResponseWriter to compress the response body.
+//
+// The decision of whether to compress the response is deferred until either
+// the response body reaches the compression threshold, the response is
+// flushed, or the handler returns.  Until then, the response status and body
+// are buffered.
+type compressWriter struct {
+	http.ResponseWriter
+	policy *compression
+
+	// encoding is the content coding negotiated with the client, or empty if the
+	// client did not accept any of the available codings.
+	encoding string
+
+	// ifNoneMatch holds the entity tags sent by the client in the If-None-Match
+	// request header.
+	ifNoneMatch []string
+
+	// head indicates that the response is to a HEAD request, and so has no
+	// body.  The response headers are set as they would be for the equivalent
+	// GET response, but nothing is encoded.
+	head bool
+
+	status  int
+	buffer  []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if code < 200 {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if c.status == 0 {
+		c.status = code
+	}
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	if !c.decided {
+		if len(c.buffer)+len(b) < c.policy.threshold {
+			c.buffer = append(c.buffer, b...)
+			return len(b), nil
+		}
+
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	if c.encoder != nil {
+		return c.encoder.Write(b)
+	}
+
+	return c.ResponseWriter.Write(b)
+}
+
+// FlushError flushes any buffered data to the client, deciding whether to
+// compress the response if that decision has not already been made.
+func (c *compressWriter) FlushError() error {
+	if !c.decided {
+		if c.status == 0 {
+			c.status = http.StatusOK
+		}
+
+		if err := c.decide(true); err != nil {
+			return err
+		}
+	}
+
+	if flusher, ok := c.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for use by
+// http.ResponseController.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// decide determines whether the response should be compressed, writes the
+// response status, and writes any buffered body data.
+//
+// The large parameter indicates whether the response body is known to have
+// reached the compression threshold, or is being streamed.  As HEAD responses
+// have no body, they are treated as large unless their Content-Length header
+// says otherwise, matching the GET response for any body of unknown length
+// that reaches the threshold.
+func (c *compressWriter) decide(large bool) error {
+	c.decided = true
+
+	header := c.ResponseWriter.Header()
+	eligible := bodyAllowed(c.status) &&
+		c.status != http.StatusPartialContent &&
+		header.Get(HeaderContentEncoding) == "" &&
+		header.Get(HeaderContentRange) == "" &&
+		c.policy.compressible(header.Get(HeaderContentType))
+
+	if eligible {
+		addVary(header, HeaderAcceptEncoding)
+	}
+
+	if eligible && (large || c.head) && c.encoding != "" && !contentLengthBelow(header, c.policy.threshold) {
+		header.Del(HeaderContentLength)
+		header.Set(HeaderContentEncoding, c.encoding)
+
+		// Entity tags must differ between encodings of the same resource.
+		if etag := header.Get(HeaderETag); strings.HasSuffix(etag, "\"") {
+			header.Set(HeaderETag, codingETag(etag, c.encoding))
+		}
+
+		// Byte ranges of the uncompressed representation cannot be served for the
+		// compressed one.
+		header.Del(HeaderAcceptRanges)
+
+		if !c.head {
+			c.encoder = c.policy.encoder(c.encoding).NewWriter(c.ResponseWriter)
+		}
+	}
+
+	// A client revalidating a compressed response must be sent the entity tag
+	// of the compressed representation it holds.
+	if c.status == http.StatusNotModified && c.encoding != "" {
+		if etag := header.Get(HeaderETag); strings.HasSuffix(etag, "\"") && matchETag(c.ifNoneMatch, codingETag(etag, c.encoding), true) {
+			header.Set(HeaderETag, codingETag(etag, c.encoding))
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+
+	if len(c.buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if c.encoder != nil {
+		_, err = c.encoder.Write(c.buffer)
+	} else {
+		_, err = c.ResponseWriter.Write(c.buffer)
+	}
+
+	c.buffer = nil
+	return err
+}
+
+// close completes the response once the handler has returned.
+func (c *compressWriter) close() error {
+	if !c.decided {
+		// Nothing was written.
+		if c.status == 0 {
+			return nil
+		}
+
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+
+	if c.encoder != nil {
+		return c.encoder.Close()
+	}
+
+	return nil
+}
+
+// contentLengthBelow tests whether the given headers declare a Content-Length
+// less than the given threshold.
+func contentLengthBelow(header http.Header, threshold int) bool {
+	length, err := strconv.ParseInt(header.Get(HeaderContentLength), 10, 64)
+	return err == nil && length < int64(threshold)
+}
+
+// codingETag returns the entity tag of the given content coding of the
+// representation with the given entity tag.
+func codingETag(etag, coding string) string {
+	return etag[:len(etag)-1] + "-" + coding + "\""
+}
@@ -0,0 +1,115 @@
+package swrv_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+func compressedStaticServer() swrv.Server {
+	files := fstest.MapFS{
+		"site.css": {
+			Data:    []byte(strings.Repeat("body { margin: 0; }\n", 200)),
+			ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+
+	return swrv.NewServer("", 0).
+		WithCompression(swrv.NewCompression()).
+		WithControllers(swrv.NewStaticController("/static", files))
+}
+
+func compressedStaticClient() *swrvtest.Client {
+	return swrvtest.New(compressedStaticServer()).WithHeader(swrv.HeaderAcceptEncoding, "gzip")
+}
+
+func TestCompressedStaticRevalidation(t *testing.T) {
+	client := compressedStaticClient()
+
+	first := client.GET("/static/site.css").Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentEncoding, "gzip").
+		NoHeader(swrv.HeaderAcceptRanges)
+
+	etag := first.Recorder().Header().Get(swrv.HeaderETag)
+	if !strings.HasSuffix(etag, "-gzip\"") {
+		t.Fatalf("expected a gzip entity tag, got %q", etag)
+	}
+
+	client.GET("/static/site.css").
+		WithHeader(swrv.HeaderIfNoneMatch, etag).
+		Expect(t).
+		Status(http.StatusNotModified).
+		Header(swrv.HeaderETag, etag).
+		Body("")
+
+	// If-Match requires a strong comparison, which the tag of the compressed
+	// representation cannot pass against the uncompressed one.
+	client.GET("/static/site.css").
+		WithHeader(swrv.HeaderIfMatch, etag).
+		Expect(t).
+		Status(http.StatusPreconditionFailed)
+
+	client.GET("/static/site.css").
+		WithHeader(swrv.HeaderIfNoneMatch, `"other-gzip"`).
+		Expect(t).
+		Status(http.StatusOK)
+}
+
+func TestCompressedStaticIfRange(t *testing.T) {
+	client := compressedStaticClient()
+
+	etag := client.GET("/static/site.css").Expect(t).
+		Status(http.StatusOK).
+		Recorder().Header().Get(swrv.HeaderETag)
+
+	// Resuming a compressed download must not be answered with a range of the
+	// uncompressed representation.
+	resumed := client.GET("/static/site.css").
+		WithHeader(swrv.HeaderRange, "bytes=0-3").
+		WithHeader(swrv.HeaderIfRange, etag).
+		Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentEncoding, "gzip").
+		NoHeader(swrv.HeaderContentRange)
+
+	reader, err := gzip.NewReader(resumed.Recorder().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body, _ := io.ReadAll(reader); string(body) != strings.Repeat("body { margin: 0; }\n", 200) {
+		t.Errorf("expected the full representation, got %d bytes", len(body))
+	}
+}
+
+func TestStaticIfRange(t *testing.T) {
+	client := swrvtest.New(compressedStaticServer())
+
+	etag := client.GET("/static/site.css").Expect(t).
+		Status(http.StatusOK).
+		NoHeader(swrv.HeaderContentEncoding).
+		Recorder().Header().Get(swrv.HeaderETag)
+
+	client.GET("/static/site.css").
+		WithHeader(swrv.HeaderRange, "bytes=0-3").
+		WithHeader(swrv.HeaderIfRange, etag).
+		Expect(t).
+		Status(http.StatusPartialContent).
+		Header(swrv.HeaderContentRange, "bytes 0-3/4000").
+		Body("body")
+
+	client.GET("/static/site.css").
+		WithHeader(swrv.HeaderRange, "bytes=0-3").
+		WithHeader(swrv.HeaderIfRange, "W/"+etag).
+		Expect(t).
+		Status(http.StatusOK).
+		NoHeader(swrv.HeaderContentRange)
+}
@@ -0,0 +1,130 @@
+package swrv
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkPreconditions evaluates the conditional request headers of the given
+// request against the given validators of the selected representation, as
+// described by RFC 9110 section 13.2.2.
+//
+// Returns 304 Not Modified or 412 Precondition Failed if the request should be
+// answered with that status instead of the representation, or 0 if the
+// representation should be sent.
+//
+// Either validator may be empty or zero if the representation does not have
+// one.
+func checkPreconditions(r *http.Request, etag string, modified time.Time) int {
+	modified = modified.Truncate(time.Second)
+
+	if values := r.Header.Values(HeaderIfMatch); len(values) > 0 {
+		if !matchETag(values, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseHTTPDate(r.Header.Get(HeaderIfUnmodifiedSince)); ok && !modified.IsZero() {
+		if modified.After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if values := r.Header.Values(HeaderIfNoneMatch); len(values) > 0 {
+		if matchETag(stripETagCodings(r, values), etag, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseHTTPDate(r.Header.Get(HeaderIfModifiedSince)); ok && safe && !modified.IsZero() {
+		if !modified.After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// matchETag tests whether any of the entity tags listed in the given If-Match
+// or If-None-Match header values matches the given entity tag, using the weak
+// comparison function if weak is set, or the strong comparison function
+// otherwise.
+//
+// The entity tag "*" matches any representation with an entity tag.
+func matchETag(values []string, etag string, weak bool) bool {
+	if len(etag) == 0 {
+		return false
+	}
+
+	for _, value := range values {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+
+			if candidate == "*" {
+				return true
+			}
+
+			if weak {
+				if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+					return true
+				}
+			} else if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// etagCodingsKey is the request context key under which a Server's response
+// compression stores the content codings whose names it appends to the entity
+// tags of compressed responses.
+type etagCodingsKey struct{}
+
+// stripETagCodings returns the given If-None-Match header values with the
+// content coding suffixes appended to entity tags by response compression
+// removed, so that they may be compared against the entity tag of the
+// uncompressed representation.
+//
+// This is only valid for the weak comparison used by If-None-Match.  If-Match
+// and If-Range require a strong comparison against the representation being
+// sent, which the tag of a differently encoded representation must not match.
+func stripETagCodings(r *http.Request, values []string) []string {
+	codings, _ := r.Context().Value(etagCodingsKey{}).([]string)
+	if len(codings) == 0 {
+		return values
+	}
+
+	out := make([]string, 0, len(values))
+
+	for _, value := range values {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+
+			for _, coding := range codings {
+				if suffix := "-" + coding + "\""; len(candidate) > len(suffix)+1 && strings.HasSuffix(candidate, suffix) {
+					candidate = candidate[:len(candidate)-len(suffix)] + "\""
+					break
+				}
+			}
+
+			out = append(out, candidate)
+		}
+	}
+
+	return out
+}
+
+// parseHTTPDate parses the given HTTP-date header value.
+func parseHTTPDate(value string) (time.Time, bool) {
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+
+	parsed, err := http.ParseTime(value)
+	return parsed, err == nil
+}
@@ -0,0 +1,127 @@
+package swrv
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// A ContentEncoder compresses response bodies using a single HTTP content
+// coding.
+//
+// Additional codings, such as brotli or zstd, may be supported by implementing
+// this interface and registering the encoder with Compression.WithEncoders.
+type ContentEncoder interface {
+	// Encoding returns the content coding token for this encoder as it appears
+	// in Accept-Encoding and Content-Encoding headers, for example "gzip".
+	Encoding() string
+
+	// NewWriter returns a writer that compresses the data written to it and
+	// writes the compressed data to the given writer.
+	//
+	// The returned writer will be closed once the response has been written,
+	// and must write any remaining compressed data on close.  If the returned
+	// writer has a Flush method of the form "Flush() error", it will be called
+	// when the response is flushed.
+	NewWriter(writer io.Writer) io.WriteCloser
+}
+
+// NewGzipEncoder returns a ContentEncoder for the "gzip" content coding that
+// compresses at the given compression level.
+//
+// Valid levels are the same as for the compress/gzip package; an invalid level
+// is replaced with gzip.DefaultCompression.
+func NewGzipEncoder(level int) ContentEncoder {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+
+	out := &gzipEncoder{}
+	out.pool.New = func() any {
+		writer, _ := gzip.NewWriterLevel(io.Discard, level)
+		return writer
+	}
+
+	return out
+}
+
+type gzipEncoder struct {
+	pool sync.Pool
+}
+
+func (g *gzipEncoder) Encoding() string {
+	return "gzip"
+}
+
+func (g *gzipEncoder) NewWriter(writer io.Writer) io.WriteCloser {
+	gz := g.pool.Get().(*gzip.Writer)
+	gz.Reset(writer)
+
+	return &pooledEncoder{writer: gz, release: func() { g.pool.Put(gz) }}
+}
+
+// NewDeflateEncoder returns a ContentEncoder for the "deflate" content coding
+// that compresses at the given compression level.
+//
+// As required by RFC 9110, the compressed data is wrapped in the zlib format.
+// Valid levels are the same as for the compress/flate package; an invalid level
+// is replaced with flate.DefaultCompression.
+func NewDeflateEncoder(level int) ContentEncoder {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+
+	out := &deflateEncoder{}
+	out.pool.New = func() any {
+		writer, _ := zlib.NewWriterLevel(io.Discard, level)
+		return writer
+	}
+
+	return out
+}
+
+type deflateEncoder struct {
+	pool sync.Pool
+}
+
+func (d *deflateEncoder) Encoding() string {
+	return "deflate"
+}
+
+func (d *deflateEncoder) NewWriter(writer io.Writer) io.WriteCloser {
+	zw := d.pool.Get().(resettableWriter)
+	zw.Reset(writer)
+
+	return &pooledEncoder{writer: zw, release: func() { d.pool.Put(zw) }}
+}
+
+// resettableWriter is a compressing writer that may be reused for a new
+// destination writer.
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(writer io.Writer)
+}
+
+// pooledEncoder wraps a compressing writer borrowed from a pool, returning it
+// to the pool when closed.
+type pooledEncoder struct {
+	writer  resettableWriter
+	release func()
+}
+
+func (p *pooledEncoder) Write(b []byte) (int, error) {
+	return p.writer.Write(b)
+}
+
+func (p *pooledEncoder) Flush() error {
+	return p.writer.Flush()
+}
+
+func (p *pooledEncoder) Close() error {
+	err := p.writer.Close()
+	p.release()
+	return err
+}
@@ -4,8 +4,10 @@ const (
 	ContentTypeApplicationGZip        = "application/gzip"
 	ContentTypeApplicationJSON        = "application/json"
 	ContentTypeApplicationLDJSON      = "application/ld+json"
+	ContentTypeApplicationNDJSON      = "application/x-ndjson"
 	ContentTypeApplicationOctetStream = "application/octet-stream"
 	ContentTypeApplicationPDF         = "application/pdf"
+	ContentTypeApplicationProblemJSON = "application/problem+json"
 	ContentTypeApplicationRTF         = "application/rtf"
 	ContentTypeApplicationXML         = "application/xml"
 	ContentTypeApplicationZip         = "application/zip"
@@ -26,11 +28,12 @@ const (
 	ContentTypeImageTiff = "image/tiff"
 	ContentTypeImageWebP = "image/webp"
 
-	ContentTypeTextCSS        = "text/css"
-	ContentTypeTextCSV        = "text/csv"
-	ContentTypeTextHTML       = "text/html"
-	ContentTypeTextJavascript = "text/javascript"
-	ContentTypeTextPlain      = "text/plain"
+	ContentTypeTextCSS         = "text/css"
+	ContentTypeTextCSV         = "text/csv"
+	ContentTypeTextEventStream = "text/event-stream"
+	ContentTypeTextHTML        = "text/html"
+	ContentTypeTextJavascript  = "text/javascript"
+	ContentTypeTextPlain       = "text/plain"
 
 	ContentTypeVideoMP4  = "video/mp4"
 	ContentTypeVideoMpeg = "video/mpeg"
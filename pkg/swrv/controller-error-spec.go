@@ -1,7 +1,7 @@
 package swrv
 
 // ErrorControllerSpec defines a simplified ControllerSpec type which may be
-// used to construct 404 or 405 error handlers.
+// used to construct error handlers such as 404 or 405 handlers.
 type ErrorControllerSpec interface {
 	// GetHandler returns the handler instance for the controller.
 	GetHandler() RequestHandler
@@ -26,7 +26,8 @@ type ErrorControllerSpec interface {
 }
 
 // NewErrorController constructs a new ErrorControllerSpec instance which may
-// be used to construct an error handling controller for 404 or 405 errors.
+// be used to construct an error handling controller for errors such as 404 or
+// 405 errors.
 func NewErrorController(handler RequestHandler) ErrorControllerSpec {
 	return &errorControllerSpec{handler: handler}
 }
@@ -58,3 +59,9 @@ func (c *errorControllerSpec) WithResponseFilters(filters ...ResponseFilter) Err
 func (c *errorControllerSpec) GetResponseFilters() []ResponseFilter {
 	return c.out
 }
+
+func defaultErrorController(problems bool, code int, detail string) ErrorControllerSpec {
+	return NewErrorController(RequestHandlerFunc(func(Request) Response {
+		return newFrameworkError(problems, code, detail)
+	}))
+}
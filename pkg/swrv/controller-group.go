@@ -0,0 +1,132 @@
+package swrv
+
+// NewGroup returns a new ControllerGroup instance which may be used to register
+// a set of controllers under a shared path prefix with shared filters and
+// serializers.
+//
+// The prefix should begin with, but not end with, a slash, for example
+// "/api/v1".
+func NewGroup(prefix string) ControllerGroup {
+	return &controllerGroup{prefix: prefix}
+}
+
+// ControllerGroup defines a group of controllers and nested groups that share
+// a common path prefix, filters, and serializers.
+//
+// Paths of controllers and nested groups registered with a ControllerGroup are
+// relative to the group's prefix.  For example, a controller with the path
+// "/users" registered with a group with the prefix "/api/v1" will handle
+// requests to "/api/v1/users".
+//
+// Group filters wrap the filters of the controllers and groups nested within
+// them.  Request filters are applied from the outermost scope inwards, that is
+// global, then group, then nested group, then controller filters, and response
+// filters are applied from the innermost scope outwards.
+type ControllerGroup interface {
+	// GetPrefix returns the URL path prefix for the group.
+	GetPrefix() string
+
+	// WithControllers adds the given ControllerSpec instances to the group.
+	WithControllers(controllers ...ControllerSpec) ControllerGroup
+
+	// GetControllers returns the ControllerSpec instances registered directly
+	// with this group.
+	GetControllers() []ControllerSpec
+
+	// WithGroups nests the given ControllerGroup instances under this group.
+	WithGroups(groups ...ControllerGroup) ControllerGroup
+
+	// GetGroups returns the ControllerGroup instances nested directly under this
+	// group.
+	GetGroups() []ControllerGroup
+
+	// WithRequestFilters appends group-specific request filters that will be
+	// applied to incoming requests for any controller in this group, after the
+	// request filters of any parent groups and before the controller-specific
+	// request filters.
+	WithRequestFilters(filters ...RequestFilter) ControllerGroup
+
+	// GetRequestFilters returns this group's group-specific RequestFilter
+	// instances.
+	GetRequestFilters() []RequestFilter
+
+	// WithResponseFilters appends group-specific response filters that will be
+	// applied to outgoing responses for any controller in this group, after the
+	// controller-specific response filters and before the response filters of
+	// any parent groups.
+	WithResponseFilters(filters ...ResponseFilter) ControllerGroup
+
+	// GetResponseFilters returns this group's group-specific ResponseFilter
+	// instances.
+	GetResponseFilters() []ResponseFilter
+
+	// WithObjectSerializers appends group-specific ObjectSerializer instances
+	// that will be used to serialize response bodies for any controller in this
+	// group.
+	//
+	// Group-specific ObjectSerializers take precedence over the serializers of
+	// any parent groups and the Server.
+	WithObjectSerializers(serializers ...ObjectSerializer) ControllerGroup
+
+	// GetObjectSerializers returns this group's group-specific ObjectSerializer
+	// instances.
+	GetObjectSerializers() []ObjectSerializer
+}
+
+type controllerGroup struct {
+	prefix      string
+	controllers []ControllerSpec
+	groups      []ControllerGroup
+	in          []RequestFilter
+	out         []ResponseFilter
+	serializers []ObjectSerializer
+}
+
+func (g *controllerGroup) GetPrefix() string {
+	return g.prefix
+}
+
+func (g *controllerGroup) WithControllers(controllers ...ControllerSpec) ControllerGroup {
+	g.controllers = append(g.controllers, controllers...)
+	return g
+}
+
+func (g *controllerGroup) GetControllers() []ControllerSpec {
+	return g.controllers
+}
+
+func (g *controllerGroup) WithGroups(groups ...ControllerGroup) ControllerGroup {
+	g.groups = append(g.groups, groups...)
+	return g
+}
+
+func (g *controllerGroup) GetGroups() []ControllerGroup {
+	return g.groups
+}
+
+func (g *controllerGroup) WithRequestFilters(filters ...RequestFilter) ControllerGroup {
+	g.in = append(g.in, filters...)
+	return g
+}
+
+func (g *controllerGroup) GetRequestFilters() []RequestFilter {
+	return g.in
+}
+
+func (g *controllerGroup) WithResponseFilters(filters ...ResponseFilter) ControllerGroup {
+	g.out = append(g.out, filters...)
+	return g
+}
+
+func (g *controllerGroup) GetResponseFilters() []ResponseFilter {
+	return g.out
+}
+
+func (g *controllerGroup) WithObjectSerializers(serializers ...ObjectSerializer) ControllerGroup {
+	g.serializers = append(g.serializers, serializers...)
+	return g
+}
+
+func (g *controllerGroup) GetObjectSerializers() []ObjectSerializer {
+	return g.serializers
+}
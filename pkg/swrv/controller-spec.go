@@ -38,6 +38,11 @@ type ControllerSpec interface {
 	// If set, the controller will only be called for matching HTTP methods.
 	//
 	// If unset, the controller will be called for any HTTP method.
+	//
+	// Unless another controller has been registered for the same path and
+	// method, controllers for GET will also answer HEAD requests with the
+	// response body omitted, and OPTIONS requests to the path will be answered
+	// automatically with an Allow header listing the path's methods.
 	ForMethods(methods ...string) ControllerSpec
 
 	// GetMethods returns the list of HTTP methods that the controller will listen
@@ -57,6 +62,22 @@ type ControllerSpec interface {
 	// If the given value string is empty, the matcher will match any value set
 	// on the target header.
 	WithRequiredHeader(header, value string) ControllerSpec
+
+	// WithMaxBodySize sets the maximum size, in bytes, of request bodies that
+	// will be accepted by the controller, overriding the maximum body size
+	// configured on the parent Server.
+	//
+	// Requests with bodies larger than the maximum size will be answered with a
+	// 413 Content Too Large error.  For compressed request bodies, the maximum
+	// size applies to both the compressed and decompressed body.
+	//
+	// A negative value disables the limit for this controller.  If unset, or if
+	// 0 is passed, the Server's maximum body size will be used.
+	WithMaxBodySize(bytes int64) ControllerSpec
+
+	// GetMaxBodySize returns the maximum request body size for this controller,
+	// or 0 if the Server's maximum body size should be used.
+	GetMaxBodySize() int64
 }
 
 type controllerSpec struct {
@@ -66,6 +87,7 @@ type controllerSpec struct {
 	out     []ResponseFilter
 	handler RequestHandler
 	headers map[string]string
+	maxBody int64
 }
 
 func (c *controllerSpec) GetPath() string {
@@ -111,3 +133,12 @@ func (c *controllerSpec) WithRequiredHeader(header, value string) ControllerSpec
 func (c *controllerSpec) GetRequiredHeaders() map[string]string {
 	return c.headers
 }
+
+func (c *controllerSpec) WithMaxBodySize(bytes int64) ControllerSpec {
+	c.maxBody = bytes
+	return c
+}
+
+func (c *controllerSpec) GetMaxBodySize() int64 {
+	return c.maxBody
+}
@@ -1,80 +1,393 @@
 package swrv
 
 import (
-	"bufio"
 	"io"
 	"net/http"
-	"strings"
-
-	"github.com/sirupsen/logrus"
+	"runtime/debug"
 )
 
 func newController(
+	route string,
 	in []RequestFilter,
 	out []ResponseFilter,
 	hand RequestHandler,
 	serial []ObjectSerializer,
-	logger *logrus.Entry,
+	deserial []ObjectDeserializer,
+	params pathParamSource,
+	errHandlers errorHandlers,
+	logger Logger,
+	maxBodySize int64,
 ) http.Handler {
 	return controller{
-		inFilters:   in,
-		outFilters:  out,
-		handler:     hand,
-		serializers: serial,
-		logger:      logger,
+		route:         route,
+		inFilters:     in,
+		outFilters:    out,
+		handler:       hand,
+		serializers:   serial,
+		deserializers: deserial,
+		params:        params,
+		errHandlers:   errHandlers,
+		logger:        logger,
+		maxBodySize:   maxBodySize,
 	}
 }
 
+// errorHandlers holds the error controllers and ErrorMapper that a controller
+// delegates to when it is unable to produce a response itself.
+//
+// Error controllers are built with an errorHandlers value containing only the
+// ErrorMapper.
+type errorHandlers struct {
+	// notAcceptable handles requests for which no acceptable ObjectSerializer
+	// could be found.
+	notAcceptable http.Handler
+
+	// internalError builds the response for requests for which processing
+	// panicked.
+	internalError *panicController
+
+	// requestTooLarge handles requests whose body exceeded the maximum body
+	// size.
+	requestTooLarge http.Handler
+
+	// mapper converts errors returned by RequestHandlerE and RequestFilterE
+	// instances into responses.
+	mapper ErrorMapper
+
+	// problems indicates whether errors generated by the framework should be
+	// returned as problem documents rather than plain text.
+	problems bool
+}
+
+// panicController builds the 500 response for a request whose processing
+// panicked, from the Server's 500 ErrorControllerSpec.
+//
+// Unlike the other error controllers, it is not a standalone http.Handler.  The
+// response it builds is sent by the controller that panicked, so that the
+// request filters that already ran, one of which may have been the cause of
+// the panic, are not run a second time.
+type panicController struct {
+	handler    RequestHandler
+	inFilters  []RequestFilter
+	outFilters []ResponseFilter
+
+	// useFilters indicates whether the response filters of the controller that
+	// panicked should be applied to the response.
+	useFilters bool
+}
+
+// errorResponse builds the Response for an error generated by the controller
+// itself.
+func (e errorHandlers) errorResponse(code int, detail string) Response {
+	return newFrameworkError(e.problems, code, detail)
+}
+
 type controller struct {
-	inFilters   []RequestFilter
-	outFilters  []ResponseFilter
-	handler     RequestHandler
-	serializers []ObjectSerializer
-	logger      *logrus.Entry
+	route         string
+	inFilters     []RequestFilter
+	outFilters    []ResponseFilter
+	handler       RequestHandler
+	serializers   []ObjectSerializer
+	deserializers []ObjectDeserializer
+	params        pathParamSource
+	errHandlers   errorHandlers
+	logger        Logger
+
+	// maxBodySize is the maximum size of request bodies accepted by the
+	// controller, or 0 if request bodies are unlimited.
+	maxBodySize int64
 }
 
-func (c controller) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
-	c.logger.Traceln("accepted request")
-	var response Response
+func (c controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := wrapRequest(r, c.deserializers, c.params, c.logger)
+
+	// Log through the request-scoped logger for the remainder of the request.
+	// As c is a copy of the controller, this does not affect other requests.
+	c.logger = request.logger
+
+	c.logger.Debug("accepted request")
+
+	recordAccessLogRoute(r.Context(), c.route)
+
+	writer := &trackingWriter{ResponseWriter: w}
+
+	defer c.recoverPanic(writer, request)
 
 	// Attempt to close the request body (if it has one) once we're done
 	// processing the request.
 	if r.Body != nil {
 		defer func(body io.ReadCloser) {
 			if err := body.Close(); err != nil {
-				c.logger.Errorln("failed to close request body")
+				c.logger.Error("failed to close request body", "error", err)
 			}
 		}(r.Body)
 	}
 
-	request := WrapRequest(r)
+	if response, ok := c.prepareBody(request, c.maxBodySize); !ok {
+		c.respond(writer, request, response)
+		return
+	}
 
 	for _, in := range c.inFilters {
-		if response = in.FilterRequest(request); response != nil {
-			c.handleResponse(writer, request, response)
+		response, err := callRequestFilter(in, request)
+
+		if response = c.resolve(request, response, err); response != nil {
+			c.respond(writer, request, response)
+			return
+		}
+	}
+
+	c.logger.Debug("processed input filters, moving to request handler")
+
+	response, err := callRequestHandler(c.handler, request)
+
+	c.respond(writer, request, c.resolve(request, response, err))
+}
+
+// respond writes the given response to the client, unless reading the request
+// body failed in a way that requires a specific error response.
+func (c controller) respond(writer http.ResponseWriter, request *request, response Response) {
+	if request.bodyTooLarge {
+		c.logger.Debug("request body exceeded the maximum body size, returning 413 error")
+
+		if c.errHandlers.requestTooLarge != nil {
+			c.errHandlers.requestTooLarge.ServeHTTP(writer, request.Raw())
 			return
 		}
+
+		response = c.errHandlers.errorResponse(413, "request body too large")
+	} else if response = c.checkMediaType(request, response); response == nil {
+		c.logger.Error("handler did not return a response")
+		response = c.errHandlers.errorResponse(500, "request handler did not return a response")
+	} else {
+		response = c.checkWebSocketHandshake(request, response)
 	}
 
-	c.logger.Traceln("processed input filters, moving to request handler")
+	c.handleResponse(writer, request, response)
+}
+
+// resolve returns the given response, or if the given error is not nil, the
+// result of passing the error through the controller's ErrorMapper.
+func (c controller) resolve(request Request, response Response, err error) Response {
+	if err == nil {
+		return response
+	}
 
-	if response = c.handler.HandleRequest(request); response != nil {
-		c.handleResponse(writer, request, response)
+	if response = c.errHandlers.mapper.MapError(request, err); response == nil {
+		response = c.errHandlers.errorResponse(500, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if response.GetCode() >= 500 {
+		c.logger.Error("request processing failed", "error", err, "status", response.GetCode())
+	} else {
+		c.logger.Debug("request processing failed", "error", err, "status", response.GetCode())
+	}
+
+	return response
+}
+
+// recoverPanic recovers from a panic raised while processing a request and
+// responds with the 500 response built by the controller's panicController.
+//
+// If the response has already been partially written, it is too late to send
+// an error response, and the connection is aborted instead.
+//
+// This method must be called directly via defer.
+func (c controller) recoverPanic(writer *trackingWriter, request *request) {
+	rec := recover()
+
+	if rec == nil {
 		return
 	}
 
-	c.logger.Errorln("handler did not return a response")
+	if rec == http.ErrAbortHandler {
+		panic(rec)
+	}
+
+	c.logger.Error("recovered from panic while processing request", "panic", rec, "stack", string(debug.Stack()))
 
-	c.handleResponse(writer, request, newEmptyResponseError("request handler did not return a response, returning 500 error"))
+	if writer.wroteHeader {
+		c.logger.Error("response was already partially written, aborting connection")
+		panic(http.ErrAbortHandler)
+	}
+
+	// Clear out any headers that were set before the panic.
+	for header := range writer.Header() {
+		delete(writer.Header(), header)
+	}
+
+	if c.errHandlers.internalError == nil {
+		c.writeErrorResponse(writer, c.errHandlers.errorResponse(500, http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	c.respondToPanic(writer, request, c.errHandlers.internalError)
+}
+
+// respondToPanic builds the 500 response for a recovered panic using the given
+// panicController, and sends it through the controller's response handling.
+//
+// As a panicking response filter or ObjectSerializer may panic again while the
+// 500 response is being handled, a second panic is recovered by writing the
+// default 500 error response directly.
+func (c controller) respondToPanic(writer *trackingWriter, request *request, panicked *panicController) {
+	defer func() {
+		rec := recover()
+
+		if rec == nil {
+			return
+		}
+
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+
+		c.logger.Error("recovered from panic while handling a panic", "panic", rec, "stack", string(debug.Stack()))
+
+		if writer.wroteHeader {
+			panic(http.ErrAbortHandler)
+		}
+
+		for header := range writer.Header() {
+			delete(writer.Header(), header)
+		}
+
+		c.writeErrorResponse(writer, c.errHandlers.errorResponse(500, http.StatusText(http.StatusInternalServerError)))
+	}()
+
+	var response Response
+
+	for _, in := range panicked.inFilters {
+		filtered, err := callRequestFilter(in, request)
+
+		if response = c.resolve(request, filtered, err); response != nil {
+			break
+		}
+	}
+
+	if response == nil {
+		handled, err := callRequestHandler(panicked.handler, request)
+		response = c.resolve(request, handled, err)
+	}
+
+	if response == nil {
+		c.logger.Error("500 controller did not return a response")
+		response = c.errHandlers.errorResponse(500, http.StatusText(http.StatusInternalServerError))
+	}
+
+	for _, out := range panicked.outFilters {
+		if response = out.FilterResponse(request, response); response == nil {
+			c.logger.Error("response filter did not return a response object, returning 500 error")
+			response = c.errHandlers.errorResponse(500, "response filter did not return a response")
+		}
+	}
+
+	if !panicked.useFilters {
+		c.outFilters = nil
+	}
+
+	c.handleResponse(writer, request, response)
+}
+
+// writeErrorResponse writes the given framework generated error response
+// directly to the given writer, bypassing the response filters and object
+// serializers.
+//
+// The given response must have an io.Reader body.
+func (c controller) writeErrorResponse(writer http.ResponseWriter, response Response) {
+	c.writeErrorHeaders(writer, response)
+
+	if _, err := io.Copy(writer, response.GetBody().(io.Reader)); err != nil {
+		c.logger.Error("failed to write error response", "error", err)
+	}
+}
+
+// writeErrorHeaders writes the headers and status code of the given framework
+// generated error response to the given writer, replacing any conflicting
+// headers previously set on the writer.
+func (c controller) writeErrorHeaders(writer http.ResponseWriter, response Response) {
+	writer.Header().Set(HeaderContentType, ContentTypeTextPlain)
+
+	response.GetHeaders().ForEach(func(header string, values []string) {
+		writer.Header()[header] = values
+	})
+
+	writer.WriteHeader(response.GetCode())
+}
+
+// checkMediaType replaces the given response with a 415 Unsupported Media Type
+// error if an attempt was made to deserialize the request body using
+// Request.ReadBodyInto and no matching ObjectDeserializer was found.
+func (c controller) checkMediaType(request *request, response Response) Response {
+	if !request.unsupportedMedia {
+		return response
+	}
+
+	c.logger.Debug("no object deserializer matched the request content type, returning 415 error")
+
+	return newUnsupportedMediaTypeError(c.errHandlers.problems, request.GetHeader(HeaderContentType))
+}
+
+// selectSerializer negotiates the ObjectSerializer that will be used to
+// serialize the given response body based on the request's Accept header.
+//
+// If no acceptable serializer is available and the controller has a 406
+// handler, nil is returned.  Controllers without a 406 handler, such as error
+// controllers, instead fall back to the first serializer that matches the
+// body, or the default serializer.
+func (c controller) selectSerializer(request Request, body any) ObjectSerializer {
+	if serializer := negotiateSerializer(request.GetHeaders(HeaderAccept), c.serializers, body); serializer != nil {
+		return serializer
+	}
+
+	if c.errHandlers.notAcceptable != nil {
+		return nil
+	}
+
+	for _, serial := range c.serializers {
+		if serial.Matches(body) {
+			return serial
+		}
+	}
+
+	return defaultObjectSerializer{}
+}
+
+// isStreamBody tests whether the given response body is a stream that is
+// written by the controller itself rather than by an ObjectSerializer.
+func isStreamBody(body any) bool {
+	switch body.(type) {
+	case *eventStream, bodyWriter, *webSocketUpgrade, *fileBody:
+		return true
+	}
+
+	return false
 }
 
 func (c controller) handleResponse(writer http.ResponseWriter, request Request, response Response) {
-	c.logger.Debugln("handling response")
+	c.logger.Debug("handling response")
 
 	for _, out := range c.outFilters {
 		if response = out.FilterResponse(request, response); response == nil {
-			c.logger.Errorln("response filter did not return a response object, returning 500 error")
-			response = newEmptyResponseError("response filter did not return a response")
+			c.logger.Error("response filter did not return a response object, returning 500 error")
+			response = c.errHandlers.errorResponse(500, "response filter did not return a response")
+		}
+	}
+
+	// Fetch the response body.
+	body := response.GetBody()
+
+	// Select the ObjectSerializer for non-stream bodies before anything is
+	// written so that a 406 error may be returned if the client will not accept
+	// any of the available content types.
+	var serializer ObjectSerializer
+	if _, isReader := body.(io.Reader); body != nil && !isReader && !isStreamBody(body) {
+		addVary(writer.Header(), HeaderAccept)
+
+		if serializer = c.selectSerializer(request, body); serializer == nil {
+			c.logger.Debug("no acceptable object serializer found, returning 406 error")
+			c.errHandlers.notAcceptable.ServeHTTP(writer, request.Raw())
+			return
 		}
 	}
 
@@ -93,95 +406,65 @@ func (c controller) handleResponse(writer http.ResponseWriter, request Request,
 		}
 	})
 
-	c.logger.Debugln("processing response body")
-
-	// Fetch the response body.
-	body := response.GetBody()
+	c.logger.Debug("processing response body")
 
-	// If there is no response body, then stop here.
-	if body == nil {
+	switch body := body.(type) {
+	case nil:
+		c.logger.Debug("response was nil, returning empty body")
 		writer.WriteHeader(response.GetCode())
-		c.logger.Traceln("response was nil, returning empty body")
-		return
-	}
 
-	if reader, ok := body.(io.ReadCloser); ok {
-		c.logger.Traceln("response body is a readcloser")
+	case *eventStream:
+		c.logger.Debug("response body is an event stream")
+		c.writeEventStream(writer, request, response.GetCode(), body)
 
-		writer.WriteHeader(response.GetCode())
+	case *webSocketUpgrade:
+		c.logger.Debug("response body is a websocket upgrade")
+		c.serveWebSocket(writer, request, body)
 
-		defer func(reader io.ReadCloser) {
-			if err := reader.Close(); err != nil {
-				c.logger.Errorln("failed to close body ReadCloser with error: ", err.Error())
-			}
-		}(reader)
+	case *fileBody:
+		c.logger.Debug("response body is a file")
+		c.writeFile(writer, request, response.GetCode(), body)
 
-		if _, err := bufio.NewWriter(writer).ReadFrom(reader); err != nil {
-			c.logger.Errorln("failed to copy body from reader to response writer: " + err.Error())
-		}
-
-		return
-	}
-
-	if reader, ok := body.(io.Reader); ok {
-		c.logger.Traceln("response body is a reader")
+	case bodyWriter:
+		c.logger.Debug("response body is a body writer")
+		c.writeBodyWriter(writer, request, response.GetCode(), body)
 
+	case io.Reader:
+		c.logger.Debug("response body is a reader")
+		setReaderLength(writer.Header(), response.GetCode(), body)
 		writer.WriteHeader(response.GetCode())
+		c.writeReader(writer, request, body)
 
-		if _, err := bufio.NewWriter(writer).ReadFrom(reader); err != nil {
-			c.logger.Errorln("failed to copy body from reader to response writer: " + err.Error())
-		}
-
-		return
+	default:
+		c.writeSerialized(writer, request, response, serializer, setContentType)
 	}
 
-	// Lookup the matching object serializer
-	var serializer ObjectSerializer
-	for _, serial := range c.serializers {
-		if serial.Matches(body) {
-			serializer = serial
-			break
-		}
-	}
-
-	// If no matching object serializer was found, fallback to the default one.
-	if serializer == nil {
-		serializer = defaultObjectSerializer{}
-	}
-
-	// If the response didn't directly set a Content-Type header, set one now.
-	if !setContentType {
-		writer.Header().Set(HeaderContentType, serializer.ContentType())
-	}
-
-	writer.WriteHeader(response.GetCode())
+	c.complete(writer, response)
+}
 
-	// Attempt to serialize the response body.
-	serialized, err := serializer.Serialize(body)
+// writeSerialized serializes the given response body using the given
+// ObjectSerializer and writes it to the client.
+func (c controller) writeSerialized(writer http.ResponseWriter, request Request, response Response, serializer ObjectSerializer, setContentType bool) {
+	// Attempt to serialize the response body before writing the response status
+	// so that a failure may still be reported to the client.
+	serialized, err := serializer.Serialize(response.GetBody())
 
 	// If we failed to serialize the response body, fallback to a bad error.
 	// TODO: handle this more gracefully?
 	if err != nil {
-		c.logger.Errorln("response body serialization failed with error: " + err.Error())
-		writer.WriteHeader(500)
-		writer.Header().Set(HeaderContentType, ContentTypeTextPlain)
-		serialized = strings.NewReader("response body serialization failed!")
-	}
-
-	// If the serializer returned something closeable, then read it and close it.
-	if reader, ok := serialized.(io.ReadCloser); ok {
-		defer func(reader io.ReadCloser) {
-			if err := reader.Close(); err != nil {
-				c.logger.Errorln("failed to close body ReadCloser with error: ", err.Error())
-			}
-		}(reader)
-	}
+		c.logger.Error("response body serialization failed", "error", err)
+		errResponse := c.errHandlers.errorResponse(500, "response body serialization failed")
+		c.writeErrorHeaders(writer, errResponse)
+		serialized = errResponse.GetBody().(io.Reader)
+	} else {
+		// If the response didn't directly set a Content-Type header, set one now.
+		if !setContentType {
+			writer.Header().Set(HeaderContentType, serializer.ContentType())
+		}
 
-	if _, err := bufio.NewWriter(writer).ReadFrom(serialized); err != nil {
-		c.logger.Errorln("failed to copy body from reader to response writer: " + err.Error())
+		setReaderLength(writer.Header(), response.GetCode(), serialized)
+		writer.WriteHeader(response.GetCode())
 	}
 
-	if fn := response.GetOnComplete(); fn != nil {
-		fn()
-	}
+	c.writeReader(writer, request, serialized)
 }
@@ -0,0 +1,92 @@
+package swrv_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+// markResponse returns a ResponseFilter that sets the given header on every
+// response it sees.
+func markResponse(header string) swrv.ResponseFilter {
+	return swrv.ResponseFilterFunc(func(_ swrv.Request, response swrv.Response) swrv.Response {
+		return response.WithHeader(header, "true")
+	})
+}
+
+func TestPanicInGlobalRequestFilter(t *testing.T) {
+	calls := 0
+
+	server := swrv.NewServer("", 0).
+		WithRequestFilters(swrv.RequestFilterFunc(func(swrv.Request) swrv.Response {
+			calls++
+			panic("filter failed")
+		})).
+		WithResponseFilters(markResponse("X-Global")).
+		WithControllers(swrv.NewController("/things", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			t.Error("expected the handler not to be called")
+			return swrv.NewResponse()
+		})).WithResponseFilters(markResponse("X-Controller")))
+
+	swrvtest.New(server).GET("/things").Expect(t).
+		Status(http.StatusInternalServerError).
+		Header("X-Global", "true").
+		Header("X-Controller", "true").
+		Body(http.StatusText(http.StatusInternalServerError))
+
+	if calls != 1 {
+		t.Errorf("expected the request filter to be called once, got %d", calls)
+	}
+}
+
+func TestPanicWith500Controller(t *testing.T) {
+	panicking := swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+		panic("handler failed")
+	})
+
+	custom := swrv.NewErrorController(swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+		return swrv.NewResponse().WithCode(http.StatusInternalServerError).WithBody("custom")
+	})).WithResponseFilters(markResponse("X-Error"))
+
+	t.Run("with global filters", func(t *testing.T) {
+		server := swrv.NewServer("", 0).
+			With500Controller(true, custom).
+			WithResponseFilters(markResponse("X-Global")).
+			WithControllers(swrv.NewController("/things", panicking))
+
+		swrvtest.New(server).GET("/things").Expect(t).
+			Status(http.StatusInternalServerError).
+			Header("X-Error", "true").
+			Header("X-Global", "true").
+			Body("custom")
+	})
+
+	t.Run("without global filters", func(t *testing.T) {
+		server := swrv.NewServer("", 0).
+			With500Controller(false, custom).
+			WithResponseFilters(markResponse("X-Global")).
+			WithControllers(swrv.NewController("/things", panicking))
+
+		swrvtest.New(server).GET("/things").Expect(t).
+			Status(http.StatusInternalServerError).
+			Header("X-Error", "true").
+			NoHeader("X-Global").
+			Body("custom")
+	})
+}
+
+func TestPanicInResponseFilter(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithResponseFilters(swrv.ResponseFilterFunc(func(swrv.Request, swrv.Response) swrv.Response {
+			panic("filter failed")
+		})).
+		WithControllers(swrv.NewController("/things", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewResponse().WithBody("ok")
+		})))
+
+	swrvtest.New(server).GET("/things").Expect(t).
+		Status(http.StatusInternalServerError).
+		Body(http.StatusText(http.StatusInternalServerError))
+}
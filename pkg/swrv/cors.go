@@ -0,0 +1,289 @@
+package swrv
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewCORS returns a new CORS policy which may be attached to a Server using
+// Server.WithCORS.
+//
+// By default, the returned policy allows requests from any origin, using any
+// of the methods registered for the target route, with any request headers.
+func NewCORS() CORS {
+	return &corsPolicy{}
+}
+
+// CORS defines a Cross-Origin Resource Sharing policy for a Server.
+//
+// When a CORS policy is attached to a Server, the Server will answer CORS
+// preflight requests for every registered controller path, advertising the
+// methods registered for that path, and will add the appropriate CORS headers
+// to responses for requests from allowed origins.
+type CORS interface {
+	// WithAllowedOrigins sets the origins that are allowed to make cross-origin
+	// requests.
+	//
+	// Origins may be given exactly, for example "https://example.com", or as a
+	// pattern containing a single "*" wildcard, for example
+	// "https://*.example.com".  The origin "*" allows requests from any origin.
+	//
+	// If unset, requests from any origin will be allowed.
+	WithAllowedOrigins(origins ...string) CORS
+
+	// WithAllowedMethods limits the methods that cross-origin requests may use.
+	//
+	// Preflight responses will advertise only the methods registered for the
+	// target route that are also in the given list.
+	//
+	// If unset, every method registered for the target route is allowed.
+	WithAllowedMethods(methods ...string) CORS
+
+	// WithAllowedHeaders sets the request headers that cross-origin requests may
+	// include.
+	//
+	// If unset, any headers requested by a preflight request will be allowed.
+	WithAllowedHeaders(headers ...string) CORS
+
+	// WithExposedHeaders sets the response headers that browsers will make
+	// available to cross-origin scripts.
+	WithExposedHeaders(headers ...string) CORS
+
+	// WithCredentials sets whether cross-origin requests may include
+	// credentials such as cookies and HTTP authentication.
+	//
+	// When credentials are allowed, the request's origin is returned in the
+	// Access-Control-Allow-Origin header instead of "*".
+	WithCredentials(allow bool) CORS
+
+	// WithMaxAge sets how long browsers may cache the results of a preflight
+	// request.
+	//
+	// If unset, no Access-Control-Max-Age header will be sent.
+	WithMaxAge(age time.Duration) CORS
+
+	policy() *corsPolicy
+}
+
+type corsPolicy struct {
+	origins     []string
+	anyOrigin   bool
+	methods     []string
+	headers     []string
+	exposed     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+func (c *corsPolicy) WithAllowedOrigins(origins ...string) CORS {
+	c.origins = append(c.origins, origins...)
+	return c
+}
+
+func (c *corsPolicy) WithAllowedMethods(methods ...string) CORS {
+	c.methods = append(c.methods, methods...)
+	return c
+}
+
+func (c *corsPolicy) WithAllowedHeaders(headers ...string) CORS {
+	c.headers = append(c.headers, headers...)
+	return c
+}
+
+func (c *corsPolicy) WithExposedHeaders(headers ...string) CORS {
+	c.exposed = append(c.exposed, headers...)
+	return c
+}
+
+func (c *corsPolicy) WithCredentials(allow bool) CORS {
+	c.credentials = allow
+	return c
+}
+
+func (c *corsPolicy) WithMaxAge(age time.Duration) CORS {
+	c.maxAge = age
+	return c
+}
+
+func (c *corsPolicy) policy() *corsPolicy {
+	out := *c
+	out.anyOrigin = len(c.origins) == 0
+
+	for _, origin := range c.origins {
+		if origin == "*" {
+			out.anyOrigin = true
+		}
+	}
+
+	return &out
+}
+
+// allowsOrigin tests whether the given request origin is allowed by the
+// policy.
+func (c *corsPolicy) allowsOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	for _, allowed := range c.origins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setOriginHeaders sets the CORS headers common to preflight and actual
+// responses for a request from the given allowed origin.
+func (c *corsPolicy) setOriginHeaders(header http.Header, origin string) {
+	if c.anyOrigin && !c.credentials {
+		header.Set(HeaderAccessControlAllowOrigin, "*")
+	} else {
+		header.Set(HeaderAccessControlAllowOrigin, origin)
+		addVary(header, HeaderOrigin)
+	}
+
+	if c.credentials {
+		header.Set(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// allowedMethods returns the methods a preflight response should advertise for
+// a route registered for the given methods.
+func (c *corsPolicy) allowedMethods(routeMethods []string, requested string) []string {
+	// Routes registered without any methods accept every method.
+	if len(routeMethods) == 0 {
+		if len(c.methods) > 0 {
+			return c.methods
+		}
+
+		return []string{requested}
+	}
+
+	if len(c.methods) == 0 {
+		return routeMethods
+	}
+
+	out := make([]string, 0, len(routeMethods))
+	for _, method := range routeMethods {
+		for _, allowed := range c.methods {
+			if strings.EqualFold(method, allowed) {
+				out = append(out, method)
+				break
+			}
+		}
+	}
+
+	return out
+}
+
+// matchOrigin tests whether the given origin matches the given allowed origin
+// pattern.
+func matchOrigin(pattern, origin string) bool {
+	star := strings.IndexByte(pattern, '*')
+
+	if star < 0 {
+		return strings.EqualFold(pattern, origin)
+	}
+
+	prefix, suffix := pattern[:star], pattern[star+1:]
+
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.EqualFold(origin[:len(prefix)], prefix) &&
+		strings.EqualFold(origin[len(origin)-len(suffix):], suffix)
+}
+
+// isPreflight tests whether the given request is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get(HeaderOrigin) != "" &&
+		r.Header.Get(HeaderAccessControlRequestMethod) != ""
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// corsHandler wraps a Server's handler to add CORS headers to responses for
+// actual (non-preflight) cross-origin requests.
+type corsHandler struct {
+	handler http.Handler
+	policy  *corsPolicy
+}
+
+func (c corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Unless every response carries the same Access-Control-Allow-Origin value,
+	// responses vary by origin whether the request included one or not.
+	if !c.policy.anyOrigin || c.policy.credentials {
+		addVary(w.Header(), HeaderOrigin)
+	}
+
+	if origin := r.Header.Get(HeaderOrigin); origin != "" && !isPreflight(r) && c.policy.allowsOrigin(origin) {
+		c.policy.setOriginHeaders(w.Header(), origin)
+
+		if len(c.policy.exposed) > 0 {
+			w.Header().Set(HeaderAccessControlExposeHeaders, strings.Join(c.policy.exposed, ", "))
+		}
+	}
+
+	c.handler.ServeHTTP(w, r)
+}
+
+// corsPreflight answers CORS preflight requests for a single route path
+// template, using the methods registered for that path.
+type corsPreflight struct {
+	policy *corsPolicy
+	path   *routePath
+}
+
+func (c *corsPreflight) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	origin := r.Header.Get(HeaderOrigin)
+	requested := r.Header.Get(HeaderAccessControlRequestMethod)
+
+	addVary(header, HeaderOrigin)
+	addVary(header, HeaderAccessControlRequestMethod)
+	addVary(header, HeaderAccessControlRequestHeaders)
+
+	if origin == "" || !c.policy.allowsOrigin(origin) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var routeMethods []string
+	if !c.path.any {
+		routeMethods = c.path.methods
+	}
+
+	methods := c.policy.allowedMethods(routeMethods, requested)
+	if !containsFold(methods, requested) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.policy.setOriginHeaders(header, origin)
+	header.Set(HeaderAccessControlAllowMethods, strings.Join(methods, ", "))
+
+	if len(c.policy.headers) > 0 {
+		header.Set(HeaderAccessControlAllowHeaders, strings.Join(c.policy.headers, ", "))
+	} else if requestedHeaders := r.Header.Values(HeaderAccessControlRequestHeaders); len(requestedHeaders) > 0 {
+		header.Set(HeaderAccessControlAllowHeaders, strings.Join(requestedHeaders, ", "))
+	}
+
+	if c.policy.maxAge > 0 {
+		header.Set(HeaderAccessControlMaxAge, strconv.Itoa(int(c.policy.maxAge/time.Second)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package swrv
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// defaultObjectDeserializer is the ObjectDeserializer used by
+// Request.ReadBodyInto when none of the registered deserializers match the
+// request's Content-Type.
+var defaultObjectDeserializer = NewDefaultJSONObjectDeserializer()
+
+func jsonMediaTypeMatcher(mediaType string) bool {
+	return mediaType == ContentTypeApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// NewDefaultJSONObjectDeserializer returns an ObjectDeserializer instance that
+// will match requests with a Content-Type of "application/json" or any
+// "+json" structured syntax suffix type, and attempt to deserialize them as
+// JSON.
+func NewDefaultJSONObjectDeserializer() ObjectDeserializer {
+	return NewJSONObjectDeserializer(jsonMediaTypeMatcher)
+}
+
+// NewJSONObjectDeserializer returns an ObjectDeserializer instance that will
+// match only the request media types that the given MediaTypeMatcherFn returns
+// true for, and will attempt to deserialize them as JSON.
+func NewJSONObjectDeserializer(fn MediaTypeMatcherFn) ObjectDeserializer {
+	return jsonObjectDeserializer{fn}
+}
+
+type jsonObjectDeserializer struct {
+	matcher MediaTypeMatcherFn
+}
+
+func (j jsonObjectDeserializer) Matches(mediaType string) bool {
+	return j.matcher(mediaType)
+}
+
+func (j jsonObjectDeserializer) Deserialize(body io.Reader, target any) error {
+	return json.NewDecoder(body).Decode(target)
+}
@@ -0,0 +1,73 @@
+package swrv_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+func TestDefaultJSONObjectDeserializer(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithControllers(swrv.NewController("/things", swrv.RequestHandlerEFunc(func(request swrv.Request) (swrv.Response, error) {
+			var body struct{ Name string }
+
+			if err := request.ReadBodyInto(&body); err != nil {
+				return nil, err
+			}
+
+			return swrv.NewResponse().WithBody(body.Name), nil
+		})).ForMethods(http.MethodPost))
+
+	client := swrvtest.New(server)
+
+	client.POST("/things").
+		WithJSONBody(map[string]string{"name": "widget"}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body("widget")
+
+	client.POST("/things").
+		WithHeader(swrv.HeaderContentType, "application/vnd.things+json").
+		WithJSONBody(map[string]string{"name": "gadget"}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body("gadget")
+
+	client.POST("/things").
+		WithHeader(swrv.HeaderContentType, "text/plain").
+		WithStringBody("widget").
+		Expect(t).
+		Status(http.StatusUnsupportedMediaType)
+}
+
+var errRejected = errors.New("rejected")
+
+// rejectingDeserializer matches JSON request bodies and refuses to
+// deserialize them.
+type rejectingDeserializer struct{}
+
+func (rejectingDeserializer) Matches(mediaType string) bool {
+	return mediaType == swrv.ContentTypeApplicationJSON
+}
+
+func (rejectingDeserializer) Deserialize(io.Reader, any) error {
+	return errRejected
+}
+
+func TestRegisteredDeserializerPrecedesDefault(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithObjectDeserializers(rejectingDeserializer{}).
+		WithErrorMapping(errRejected, swrv.NewStatusErrorMapper(http.StatusUnprocessableEntity)).
+		WithControllers(swrv.NewController("/things", swrv.RequestHandlerEFunc(func(request swrv.Request) (swrv.Response, error) {
+			return swrv.NewResponse(), request.ReadBodyInto(new(map[string]string))
+		})).ForMethods(http.MethodPost))
+
+	swrvtest.New(server).POST("/things").
+		WithJSONBody(map[string]string{"name": "widget"}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,36 @@
+package swrv
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrUnsupportedMediaType is returned by Request.ReadBodyInto when no
+// ObjectDeserializer registered with the Server matches the Content-Type of the
+// incoming request.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// MediaTypeMatcherFn defines a function that may be used as a request media
+// type matcher in an ObjectDeserializer.
+//
+// The media type passed to the function will be lowercase and stripped of any
+// parameters, for example "application/json".
+type MediaTypeMatcherFn = func(mediaType string) bool
+
+// An ObjectDeserializer is used to deserialize incoming request bodies into
+// objects that may be used by RequestFilter and RequestHandler instances.
+type ObjectDeserializer interface {
+
+	// Matches tests whether request bodies of the given media type may be
+	// deserialized by the current ObjectDeserializer.
+	//
+	// The given media type will be lowercase and stripped of any parameters.
+	//
+	// If this method returns true, Deserialize will be called on the request body
+	// and no further ObjectDeserializers will be tested.
+	Matches(mediaType string) bool
+
+	// Deserialize reads the given request body and deserializes it into the
+	// given target value, which will generally be a pointer.
+	Deserialize(body io.Reader, target any) error
+}
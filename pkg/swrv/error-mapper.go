@@ -0,0 +1,108 @@
+package swrv
+
+import (
+	"errors"
+	"net/http"
+)
+
+// An ErrorMapper converts errors returned by RequestHandlerE and RequestFilterE
+// instances into Response instances that will be returned to the HTTP client.
+//
+// Responses returned by an ErrorMapper are passed through the controller's
+// ResponseFilters like any other Response.
+type ErrorMapper interface {
+
+	// MapError converts the given error into a Response.
+	//
+	// If this method returns nil, the error will be passed on to the next
+	// applicable ErrorMapper.
+	MapError(request Request, err error) Response
+}
+
+// An ErrorMapperFunc is a function that implements the ErrorMapper interface.
+type ErrorMapperFunc func(request Request, err error) Response
+
+func (e ErrorMapperFunc) MapError(request Request, err error) Response {
+	return e(request, err)
+}
+
+// DefaultErrorMapper returns the ErrorMapper used by a Server when no custom
+// fallback ErrorMapper has been configured.
+//
+// The default ErrorMapper converts Problem instances into problem document
+// responses, HTTPError instances into responses with the error's status code
+// and public message, ErrUnsupportedMediaType into a 415 Unsupported Media
+// Type response, ErrRequestBodyTooLarge into a 413 Content Too Large response,
+// and all other errors into a 500 Internal Server Error response.
+func DefaultErrorMapper() ErrorMapper {
+	return ErrorMapperFunc(defaultMapError)
+}
+
+// NewStatusErrorMapper returns an ErrorMapper that converts any error into a
+// response with the given status code and a plain-text body containing the
+// standard status text for that code, e.g. "Not Found" for 404.
+func NewStatusErrorMapper(code int) ErrorMapper {
+	return ErrorMapperFunc(func(Request, error) Response {
+		return newStatusTextResponse(code)
+	})
+}
+
+func defaultMapError(request Request, err error) Response {
+	return mapFrameworkError(false, request, err)
+}
+
+// mapFrameworkError implements the DefaultErrorMapper, optionally producing
+// problem documents in place of plain-text error responses.
+func mapFrameworkError(problems bool, request Request, err error) Response {
+	var problem *Problem
+	var httpErr *HTTPError
+
+	if errors.As(err, &problem) {
+		return problem.ToResponse()
+	}
+
+	if errors.As(err, &httpErr) {
+		return newFrameworkError(problems, httpErr.Code, httpErr.PublicMessage())
+	}
+
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return newUnsupportedMediaTypeError(problems, request.GetHeader(HeaderContentType))
+	}
+
+	if errors.Is(err, ErrRequestBodyTooLarge) {
+		return newFrameworkError(problems, http.StatusRequestEntityTooLarge, "request body too large")
+	}
+
+	return newFrameworkError(problems, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+type errorMapping struct {
+	target error
+	mapper ErrorMapper
+}
+
+// compositeErrorMapper tries each of its registered errors.Is mappings in
+// order, falling back to the fallback mapper if none match.
+type compositeErrorMapper struct {
+	mappings []errorMapping
+	fallback ErrorMapper
+	problems bool
+}
+
+func (c compositeErrorMapper) MapError(request Request, err error) Response {
+	for _, mapping := range c.mappings {
+		if errors.Is(err, mapping.target) {
+			if response := mapping.mapper.MapError(request, err); response != nil {
+				return response
+			}
+		}
+	}
+
+	if c.fallback != nil {
+		if response := c.fallback.MapError(request, err); response != nil {
+			return response
+		}
+	}
+
+	return mapFrameworkError(c.problems, request, err)
+}
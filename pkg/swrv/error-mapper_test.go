@@ -0,0 +1,24 @@
+package swrv_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+func TestStatusErrorMapper(t *testing.T) {
+	errMissing := errors.New("missing")
+
+	server := swrv.NewServer("", 0).
+		WithErrorMapping(errMissing, swrv.NewStatusErrorMapper(http.StatusNotFound)).
+		WithControllers(swrv.NewController("/things", swrv.RequestHandlerEFunc(func(swrv.Request) (swrv.Response, error) {
+			return nil, errMissing
+		})))
+
+	swrvtest.New(server).GET("/things").Expect(t).
+		Status(http.StatusNotFound).
+		Body(http.StatusText(http.StatusNotFound))
+}
@@ -0,0 +1,382 @@
+package swrv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewFileResponse returns a new Response that sends the given content to the
+// client as a file download, supporting range requests so that clients such as
+// download managers and media players may fetch parts of the file or resume an
+// interrupted download.
+//
+// The name is used as the file name in an attachment Content-Disposition header
+// and to determine the Content-Type header from the file extension, unless
+// those headers have been set on the returned Response.  If name is empty, no
+// Content-Disposition header is sent.
+//
+// If modified is not zero, it is sent in the Last-Modified header.  Conditional
+// requests are evaluated against the modification time and any ETag header set
+// on the returned Response, and are answered with a 304 Not Modified or 412
+// Precondition Failed response as appropriate.
+//
+// Range requests are only honored for GET requests with a 200 response code,
+// and are answered with a 206 Partial Content response, using a
+// multipart/byteranges body if more than one range was requested, or with a
+// 416 Range Not Satisfiable error if none of the requested ranges overlap the
+// content.  An If-Range header is honored using either validator.
+//
+// If the content implements io.Closer, it is closed once the response has been
+// written.
+//
+// Example:
+//
+//	file, err := os.Open(path)
+//	if err != nil {
+//	  return nil, err
+//	}
+//	info, err := file.Stat()
+//	if err != nil {
+//	  return nil, err
+//	}
+//	return swrv.NewFileResponse(file, "export.csv", info.ModTime()), nil
+func NewFileResponse(content io.ReadSeeker, name string, modified time.Time) Response {
+	return NewResponse().WithBody(&fileBody{content: content, name: name, modified: modified})
+}
+
+// fileBody is the body of a Response created by NewFileResponse.
+type fileBody struct {
+	content  io.ReadSeeker
+	name     string
+	modified time.Time
+}
+
+// httpRange is a single byte range of a range request.
+type httpRange struct {
+	start, length int64
+}
+
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// errRangeNotSatisfiable is returned by parseRange when none of the requested
+// ranges overlap the content.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// writeFile writes the given file response body to the client, answering
+// conditional and range requests.
+//
+// If the file cannot be sent, for example because a precondition failed or the
+// requested range is not satisfiable, an error response is written in its
+// place.
+func (c controller) writeFile(writer http.ResponseWriter, request Request, code int, body *fileBody) {
+	if closer, ok := body.content.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				c.logger.Error("failed to close file response content", "error", err)
+			}
+		}()
+	}
+
+	size, err := body.content.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = body.content.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		c.replaceResponse(writer, request, c.resolve(request, nil, err))
+		return
+	}
+
+	header := writer.Header()
+
+	if len(header.Get(HeaderContentType)) == 0 {
+		if len(body.name) > 0 {
+			header.Set(HeaderContentType, staticContentType(body.name))
+		} else {
+			header.Set(HeaderContentType, ContentTypeApplicationOctetStream)
+		}
+	}
+
+	if len(body.name) > 0 && len(header.Get(HeaderContentDisposition)) == 0 {
+		header.Set(HeaderContentDisposition, contentDisposition(body.name))
+	}
+
+	if !body.modified.IsZero() && len(header.Get(HeaderLastModified)) == 0 {
+		header.Set(HeaderLastModified, body.modified.UTC().Format(http.TimeFormat))
+	}
+
+	if !bodyAllowed(code) {
+		writer.WriteHeader(code)
+		return
+	}
+
+	if code != http.StatusOK {
+		c.writeFileRange(writer, request, code, body, httpRange{0, size})
+		return
+	}
+
+	header.Set(HeaderAcceptRanges, "bytes")
+
+	raw := request.Raw()
+
+	switch checkPreconditions(raw, header.Get(HeaderETag), body.modified) {
+	case http.StatusNotModified:
+		header.Del(HeaderContentType)
+		header.Del(HeaderContentDisposition)
+		writer.WriteHeader(http.StatusNotModified)
+		return
+
+	case http.StatusPreconditionFailed:
+		c.logger.Debug("file response precondition failed, returning 412 error")
+		c.replaceResponse(writer, request, c.errHandlers.errorResponse(http.StatusPreconditionFailed, "precondition failed"))
+		return
+	}
+
+	var ranges []httpRange
+
+	if raw.Method == http.MethodGet && len(raw.Header.Get(HeaderRange)) > 0 && ifRangeMatches(raw, header.Get(HeaderETag), body.modified) {
+		if ranges, err = parseRange(raw.Header.Get(HeaderRange), size); err != nil {
+			c.logger.Debug("requested range not satisfiable, returning 416 error", "range", raw.Header.Get(HeaderRange))
+			c.replaceResponse(writer, request, c.errHandlers.errorResponse(http.StatusRequestedRangeNotSatisfiable, "requested range not satisfiable").
+				WithHeader(HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10)))
+			return
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		c.writeFileRange(writer, request, http.StatusOK, body, httpRange{0, size})
+
+	case 1:
+		header.Set(HeaderContentRange, ranges[0].contentRange(size))
+		c.writeFileRange(writer, request, http.StatusPartialContent, body, ranges[0])
+
+	default:
+		c.writeFileRanges(writer, body, ranges, size)
+	}
+}
+
+// writeFileRange writes the given range of the file to the client as the
+// response body.
+func (c controller) writeFileRange(writer http.ResponseWriter, request Request, code int, body *fileBody, span httpRange) {
+	writer.Header().Set(HeaderContentLength, strconv.FormatInt(span.length, 10))
+	writer.WriteHeader(code)
+
+	if request.Method() == http.MethodHead {
+		return
+	}
+
+	if err := copyRange(writer, body.content, span); err != nil {
+		c.logger.Error("failed to copy file range to response writer", "error", err)
+	}
+}
+
+// writeFileRanges writes the given ranges of the file to the client as a
+// multipart/byteranges response body.
+func (c controller) writeFileRanges(writer http.ResponseWriter, body *fileBody, ranges []httpRange, size int64) {
+	header := writer.Header()
+	contentType := header.Get(HeaderContentType)
+
+	partHeader := func(span httpRange) textproto.MIMEHeader {
+		return textproto.MIMEHeader{
+			HeaderContentType:  {contentType},
+			HeaderContentRange: {span.contentRange(size)},
+		}
+	}
+
+	// Write the multipart framing without the content first to compute the
+	// length of the response body.
+	var counter byteCounter
+	parts := multipart.NewWriter(&counter)
+
+	for _, span := range ranges {
+		_, _ = parts.CreatePart(partHeader(span))
+		counter += byteCounter(span.length)
+	}
+
+	_ = parts.Close()
+
+	header.Set(HeaderContentType, "multipart/byteranges; boundary="+parts.Boundary())
+	header.Set(HeaderContentLength, strconv.FormatInt(int64(counter), 10))
+	writer.WriteHeader(http.StatusPartialContent)
+
+	out := multipart.NewWriter(writer)
+	_ = out.SetBoundary(parts.Boundary())
+
+	for _, span := range ranges {
+		part, err := out.CreatePart(partHeader(span))
+		if err == nil {
+			err = copyRange(part, body.content, span)
+		}
+
+		if err != nil {
+			c.logger.Error("failed to copy file range to response writer", "error", err)
+			return
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		c.logger.Error("failed to complete multipart response", "error", err)
+	}
+}
+
+// replaceResponse writes the given response in place of a response whose body
+// could not be sent.
+//
+// The response being replaced has already passed through the controller's
+// response filters, so the replacement is written without filtering it again.
+// The headers set from the replaced response are kept, other than those that
+// describe its body.
+func (c controller) replaceResponse(writer http.ResponseWriter, request Request, response Response) {
+	header := writer.Header()
+
+	for _, name := range []string{HeaderContentType, HeaderContentLength, HeaderContentEncoding, HeaderContentDisposition, HeaderContentRange, HeaderAcceptRanges} {
+		header.Del(name)
+	}
+
+	c.outFilters = nil
+	c.handleResponse(writer, request, response)
+}
+
+// copyRange copies the given range of the given content to the given writer.
+func copyRange(writer io.Writer, content io.ReadSeeker, span httpRange) error {
+	if _, err := content.Seek(span.start, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err := io.CopyN(writer, content, span.length)
+	return err
+}
+
+// byteCounter is an io.Writer that counts the bytes written to it.
+type byteCounter int64
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	*b += byteCounter(len(p))
+	return len(p), nil
+}
+
+// contentDisposition returns an attachment Content-Disposition header value for
+// the given file name.
+func contentDisposition(name string) string {
+	if value := mime.FormatMediaType("attachment", map[string]string{"filename": name}); len(value) > 0 {
+		return value
+	}
+
+	return "attachment"
+}
+
+// ifRangeMatches tests whether the If-Range header of the given request, if it
+// has one, matches the given validators, meaning that the request's Range header
+// should be honored.
+//
+// Entity tags are compared using the strong comparison function, so the tag of
+// a compressed representation never matches the uncompressed file.
+func ifRangeMatches(r *http.Request, etag string, modified time.Time) bool {
+	value := r.Header.Get(HeaderIfRange)
+
+	switch {
+	case len(value) == 0:
+		return true
+	case strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "W/\""):
+		return matchETag([]string{value}, etag, false)
+	}
+
+	since, ok := parseHTTPDate(value)
+	return ok && !modified.IsZero() && modified.Truncate(time.Second).Equal(since)
+}
+
+// parseRange parses the given Range header value, as described by RFC 9110
+// section 14.1.2, against content of the given size.
+//
+// Returns no ranges if the header should be ignored, either because it is
+// malformed, or because its ranges overlap so heavily that serving them would
+// send more than the full content.  Returns errRangeNotSatisfiable if none of
+// the requested ranges overlap the content.
+func parseRange(value string, size int64) ([]httpRange, error) {
+	unit, specs, ok := strings.Cut(value, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+		return nil, nil
+	}
+
+	var ranges []httpRange
+	var total int64
+
+	requested := 0
+
+	for _, spec := range strings.Split(specs, ",") {
+		if spec = strings.TrimSpace(spec); len(spec) == 0 {
+			continue
+		}
+
+		requested++
+
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, nil
+		}
+
+		var span httpRange
+
+		if len(first) == 0 {
+			// A suffix range, selecting the final bytes of the content.
+			suffix, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, nil
+			}
+
+			if suffix == 0 || size == 0 {
+				continue
+			}
+
+			span = httpRange{start: max(size-suffix, 0), length: min(suffix, size)}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, nil
+			}
+
+			end := size - 1
+			if len(last) > 0 {
+				if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+					return nil, nil
+				}
+			}
+
+			if start >= size {
+				continue
+			}
+
+			span = httpRange{start: start, length: min(end, size-1) - start + 1}
+		}
+
+		ranges = append(ranges, span)
+		total += span.length
+	}
+
+	if requested == 0 {
+		return nil, nil
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	if len(ranges) > 1 && total > size {
+		return nil, nil
+	}
+
+	return ranges, nil
+}
@@ -0,0 +1,68 @@
+package swrv_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+func TestFileResponseReplacement(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var completed, filtered int
+
+	server := swrv.NewServer("", 0).
+		WithResponseFilters(swrv.ResponseFilterFunc(func(_ swrv.Request, response swrv.Response) swrv.Response {
+			filtered++
+			return response.WithHeader("X-Filtered", "true")
+		})).
+		WithControllers(swrv.NewController("/export.csv", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewFileResponse(strings.NewReader("a,b,c\n1,2,3\n"), "export.csv", modified).
+				OnComplete(func() { completed++ })
+		})))
+
+	client := swrvtest.New(server)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"ok", "", "", http.StatusOK},
+		{"not modified", swrv.HeaderIfModifiedSince, modified.Format(http.TimeFormat), http.StatusNotModified},
+		{"precondition failed", swrv.HeaderIfUnmodifiedSince, modified.Add(-time.Hour).Format(http.TimeFormat), http.StatusPreconditionFailed},
+		{"range not satisfiable", swrv.HeaderRange, "bytes=100-200", http.StatusRequestedRangeNotSatisfiable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			completed, filtered = 0, 0
+
+			request := client.GET("/export.csv")
+			if len(test.header) > 0 {
+				request.WithHeader(test.header, test.value)
+			}
+
+			expect := request.Expect(t).
+				Status(test.status).
+				Header("X-Filtered", "true")
+
+			if test.status == http.StatusRequestedRangeNotSatisfiable {
+				expect.Header(swrv.HeaderContentRange, "bytes */12")
+			}
+
+			if completed != 1 {
+				t.Errorf("expected the OnComplete callback to be called once, got %d", completed)
+			}
+
+			if filtered != 1 {
+				t.Errorf("expected the response filter to be called once, got %d", filtered)
+			}
+		})
+	}
+}
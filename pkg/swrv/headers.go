@@ -2,6 +2,7 @@ package swrv
 
 const (
 	HeaderAccept                        = "Accept"
+	HeaderAcceptEncoding                = "Accept-Encoding"
 	HeaderAcceptRanges                  = "Accept-Ranges"
 	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
 	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
@@ -31,12 +32,21 @@ const (
 	HeaderForwarded                     = "Forwarded"
 	HeaderFrom                          = "From"
 	HeaderHost                          = "Host"
+	HeaderIfMatch                       = "If-Match"
+	HeaderIfModifiedSince               = "If-Modified-Since"
+	HeaderIfNoneMatch                   = "If-None-Match"
 	HeaderIfRange                       = "If-Range"
+	HeaderIfUnmodifiedSince             = "If-Unmodified-Since"
+	HeaderLastEventID                   = "Last-Event-ID"
 	HeaderLastModified                  = "Last-Modified"
 	HeaderLocation                      = "Location"
 	HeaderRange                         = "Range"
 	HeaderReferer                       = "Referer"
 	HeaderRefererPolicy                 = "RefererPolicy"
+	HeaderSecWebSocketAccept            = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketKey               = "Sec-WebSocket-Key"
+	HeaderSecWebSocketProtocol          = "Sec-WebSocket-Protocol"
+	HeaderSecWebSocketVersion           = "Sec-WebSocket-Version"
 	HeaderServer                        = "Server"
 	HeaderSetCookie                     = "Set-Cookie"
 	HeaderOrigin                        = "Origin"
@@ -47,4 +57,5 @@ const (
 	HeaderUserAgent                     = "User-Agent"
 	HeaderVary                          = "Vary"
 	HeaderVia                           = "Via"
+	HeaderXRequestID                    = "X-Request-ID"
 )
@@ -0,0 +1,54 @@
+package swrv
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPError is an error type carrying the HTTP status code and client-facing
+// message that should be used when the error is returned from a
+// RequestHandlerE or RequestFilterE.
+//
+// The wrapped cause, if any, is logged by the Server but never sent to the HTTP
+// client.
+type HTTPError struct {
+	// Code is the HTTP status code that should be returned to the client.
+	Code int
+
+	// Message is the public error message that should be returned to the
+	// client.
+	//
+	// If empty, the standard status text for the Code will be used.
+	Message string
+
+	// Cause is the underlying error, if any.
+	Cause error
+}
+
+// NewHTTPError returns a new HTTPError instance with the given status code,
+// public message, and optional cause.
+func NewHTTPError(code int, message string, cause error) *HTTPError {
+	return &HTTPError{Code: code, Message: message, Cause: cause}
+}
+
+// PublicMessage returns the message that should be returned to the HTTP
+// client.
+func (e *HTTPError) PublicMessage() string {
+	if len(e.Message) > 0 {
+		return e.Message
+	}
+
+	return http.StatusText(e.Code)
+}
+
+func (e *HTTPError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %s", e.Code, e.PublicMessage(), e.Cause.Error())
+	}
+
+	return fmt.Sprintf("%d %s", e.Code, e.PublicMessage())
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
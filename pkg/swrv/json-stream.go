@@ -0,0 +1,262 @@
+package swrv
+
+import (
+	"encoding/json"
+	"iter"
+	"sync"
+	"time"
+)
+
+// jsonStreamFlushInterval is the interval at which a streamed JSON response
+// body is flushed to the client.
+//
+// The first item is flushed as soon as it has been written, while later items
+// are batched and flushed together once the interval has elapsed, whether or
+// not the iterator has yielded another item.
+const jsonStreamFlushInterval = 100 * time.Millisecond
+
+// NewNDJSONResponse returns a new Response that streams each item yielded by
+// the given iterator to the client as newline-delimited JSON, using the
+// "application/x-ndjson" content type.
+//
+// Items are serialized one at a time as the iterator yields them, and the
+// response is flushed to the client periodically.  Iteration is stopped if the
+// client disconnects.
+//
+// Example:
+//
+//	return swrv.NewNDJSONResponse(func(yield func(Row) bool) {
+//	  for cursor.Next() {
+//	    if !yield(cursor.Row()) {
+//	      return
+//	    }
+//	  }
+//	})
+func NewNDJSONResponse[T any](items iter.Seq[T]) Response {
+	return NewNDJSONResponseE(withNilErrors(items))
+}
+
+// NewNDJSONResponseE returns a new Response that streams each item yielded by
+// the given iterator to the client as newline-delimited JSON, stopping at the
+// first error yielded by the iterator.
+//
+// If the iterator yields an error before any items, the error is converted into
+// a response by the Server's ErrorMapper.  Otherwise, the response is ended and
+// the connection aborted so that the client sees an incomplete response.
+//
+// See NewNDJSONResponse.
+func NewNDJSONResponseE[T any](items iter.Seq2[T, error]) Response {
+	return NewResponse().
+		WithHeader(HeaderContentType, ContentTypeApplicationNDJSON).
+		WithBodyWriter(func(writer StreamWriter) error {
+			return writeJSONStream(writer, items, ndjsonFormat)
+		})
+}
+
+// NewJSONArrayResponse returns a new Response that streams the items yielded by
+// the given iterator to the client as a single JSON array, using the
+// "application/json" content type.
+//
+// Items are serialized one at a time as the iterator yields them, and the
+// response is flushed to the client periodically.  Iteration is stopped if the
+// client disconnects.
+func NewJSONArrayResponse[T any](items iter.Seq[T]) Response {
+	return NewJSONArrayResponseE(withNilErrors(items))
+}
+
+// NewJSONArrayResponseE returns a new Response that streams the items yielded
+// by the given iterator to the client as a single JSON array, stopping at the
+// first error yielded by the iterator.
+//
+// If the iterator yields an error before any items, the error is converted into
+// a response by the Server's ErrorMapper.  Otherwise, the response is ended and
+// the connection aborted so that the client sees an incomplete response.
+//
+// See NewJSONArrayResponse.
+func NewJSONArrayResponseE[T any](items iter.Seq2[T, error]) Response {
+	return NewResponse().
+		WithHeader(HeaderContentType, ContentTypeApplicationJSON).
+		WithBodyWriter(func(writer StreamWriter) error {
+			return writeJSONStream(writer, items, jsonArrayFormat)
+		})
+}
+
+// ChannelSeq returns an iterator over the values received from the given
+// channel, for use with the streaming response constructors.
+//
+// The iterator ends when the channel is closed.  If iteration is stopped early,
+// for example because the client disconnected, values are no longer received
+// from the channel, so producers should also watch the request's context.
+func ChannelSeq[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value := range ch {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// withNilErrors adapts the given iterator to an iterator that never yields an
+// error.
+func withNilErrors[T any](items iter.Seq[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// jsonStreamFormat defines the framing of the items in a streamed JSON response
+// body.
+type jsonStreamFormat struct {
+	// prefix and suffix are written before the first item and after the last.
+	prefix, suffix string
+
+	// separator is written between items.
+	separator string
+
+	// terminator is written after every item.
+	terminator string
+}
+
+var (
+	ndjsonFormat    = jsonStreamFormat{terminator: "\n"}
+	jsonArrayFormat = jsonStreamFormat{prefix: "[", suffix: "]\n", separator: ","}
+)
+
+// writeJSONStream serializes each item yielded by the given iterator to the
+// given writer using the given format.
+//
+// Nothing is written until the first item has been yielded, so that an error
+// yielded before any items may still be returned as an error response.
+func writeJSONStream[T any](writer StreamWriter, items iter.Seq2[T, error], format jsonStreamFormat) error {
+	stream := &jsonStreamWriter{writer: writer}
+	defer stream.stop()
+
+	var buffer []byte
+
+	count := 0
+
+	for item, err := range items {
+		if err != nil {
+			return err
+		}
+
+		if err = writer.Context().Err(); err != nil {
+			return err
+		}
+
+		if count == 0 {
+			buffer = append(buffer[:0], format.prefix...)
+		} else {
+			buffer = append(buffer[:0], format.separator...)
+		}
+
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+
+		buffer = append(buffer, encoded...)
+		buffer = append(buffer, format.terminator...)
+
+		if err = stream.write(buffer, count == 0); err != nil {
+			return err
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		buffer = append(buffer[:0], format.prefix...)
+	} else {
+		buffer = buffer[:0]
+	}
+
+	return stream.write(append(buffer, format.suffix...), false)
+}
+
+// jsonStreamWriter writes streamed JSON response data, flushing data that has
+// been written but not yet flushed once jsonStreamFlushInterval has elapsed,
+// so that items are sent promptly even when the iterator blocks waiting for
+// the next item.
+type jsonStreamWriter struct {
+	writer StreamWriter
+
+	mutex   sync.Mutex
+	pending bool
+	ticker  *time.Ticker
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// write writes the given data, flushing it immediately if flush is set.
+//
+// The background flushing is started by the first call to write, so nothing is
+// sent to the client until then.
+func (j *jsonStreamWriter) write(data []byte, flush bool) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if _, err := j.writer.Write(data); err != nil {
+		return err
+	}
+
+	if flush {
+		j.pending = false
+		return j.writer.Flush()
+	}
+
+	j.pending = true
+
+	if j.ticker == nil {
+		j.start()
+	}
+
+	return nil
+}
+
+// start starts the background flushing.
+func (j *jsonStreamWriter) start() {
+	j.ticker = time.NewTicker(jsonStreamFlushInterval)
+	j.done = make(chan struct{})
+	j.stopped = make(chan struct{})
+
+	go func() {
+		defer close(j.stopped)
+
+		for {
+			select {
+			case <-j.done:
+				return
+			case <-j.writer.Context().Done():
+				return
+			case <-j.ticker.C:
+				j.mutex.Lock()
+				if j.pending {
+					j.pending = false
+					// A failed flush is returned by the next write.
+					_ = j.writer.Flush()
+				}
+				j.mutex.Unlock()
+			}
+		}
+	}()
+}
+
+// stop stops the background flushing, waiting for any in-progress flush to
+// complete so that the writer is not used after the stream has ended.
+func (j *jsonStreamWriter) stop() {
+	j.mutex.Lock()
+	started := j.ticker != nil
+	j.mutex.Unlock()
+
+	if started {
+		j.ticker.Stop()
+		close(j.done)
+		<-j.stopped
+	}
+}
@@ -0,0 +1,57 @@
+package swrv_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+func TestNDJSONResponseFlushesIdleChannel(t *testing.T) {
+	items := make(chan int)
+
+	server := swrv.NewServer("", 0).
+		WithControllers(swrv.NewController("/items", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewNDJSONResponse(swrv.ChannelSeq(items))
+		})))
+
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	// Send two items in quick succession, then go quiet without closing the
+	// channel until the test has finished.
+	go func() {
+		items <- 1
+		items <- 2
+	}()
+	defer close(items)
+
+	response, err := http.Get(httpServer.URL + "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for _, expected := range []string{"1", "2"} {
+		select {
+		case line := <-lines:
+			if line != expected {
+				t.Fatalf("expected line %q, got %q", expected, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("item %s was not flushed while the channel was idle", expected)
+		}
+	}
+}
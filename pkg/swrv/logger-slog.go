@@ -0,0 +1,38 @@
+package swrv
+
+import "log/slog"
+
+// NewSlogLogger returns a Logger that logs through the given slog.Logger.
+//
+// If the given slog.Logger is nil, the default slog.Logger will be used.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, args ...any) {
+	s.logger.Debug(msg, args...)
+}
+
+func (s slogLogger) Info(msg string, args ...any) {
+	s.logger.Info(msg, args...)
+}
+
+func (s slogLogger) Warn(msg string, args ...any) {
+	s.logger.Warn(msg, args...)
+}
+
+func (s slogLogger) Error(msg string, args ...any) {
+	s.logger.Error(msg, args...)
+}
+
+func (s slogLogger) With(args ...any) Logger {
+	return slogLogger{s.logger.With(args...)}
+}
@@ -0,0 +1,29 @@
+package swrv
+
+// Logger is the structured logging interface that swrv servers log through.
+//
+// Each method accepts a message followed by alternating key/value pairs, in
+// the same form accepted by the standard library's log/slog package.  For
+// example:
+//
+//	logger.Info("starting server", "address", addr)
+//
+// Adapters are available for log/slog via NewSlogLogger, and for
+// github.com/sirupsen/logrus in the swrvlogrus package.
+type Logger interface {
+	// Debug logs the given message and key/value pairs at the debug level.
+	Debug(msg string, args ...any)
+
+	// Info logs the given message and key/value pairs at the info level.
+	Info(msg string, args ...any)
+
+	// Warn logs the given message and key/value pairs at the warn level.
+	Warn(msg string, args ...any)
+
+	// Error logs the given message and key/value pairs at the error level.
+	Error(msg string, args ...any)
+
+	// With returns a Logger that includes the given key/value pairs with every
+	// message it logs.
+	With(args ...any) Logger
+}
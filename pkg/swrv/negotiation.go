@@ -0,0 +1,251 @@
+package swrv
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange represents a single media range parsed from an Accept header.
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  map[string]string
+	quality float64
+}
+
+// specificity returns a ranking of how specific the media range is, with
+// higher values taking precedence over lower values when multiple ranges match
+// the same media type.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2 + len(m.params)
+	}
+}
+
+// matches tests whether the given parsed media type falls within this media
+// range.
+func (m mediaRange) matches(typ, subtype string, params map[string]string) bool {
+	if m.typ != "*" && m.typ != typ {
+		return false
+	}
+
+	if m.subtype != "*" && m.subtype != subtype {
+		return false
+	}
+
+	for key, val := range m.params {
+		if !strings.EqualFold(params[key], val) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// acceptRanges is the list of media ranges parsed from a request's Accept
+// header.
+type acceptRanges []mediaRange
+
+// parseAccept parses the given Accept header values into a list of media
+// ranges as described by RFC 9110 section 12.5.1.
+//
+// Malformed media ranges are skipped.  If no valid media ranges could be
+// parsed, the returned list will be empty, which is treated as accepting
+// anything.
+func parseAccept(values []string) acceptRanges {
+	var out acceptRanges
+
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+
+			if len(part) == 0 {
+				continue
+			}
+
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			typ, subtype, ok := strings.Cut(mediaType, "/")
+			if !ok || (typ == "*" && subtype != "*") {
+				continue
+			}
+
+			rng := mediaRange{typ: typ, subtype: subtype, quality: 1}
+
+			// Per RFC 9110, the "q" parameter separates media type parameters from
+			// accept extension parameters, however mime.ParseMediaType does not
+			// preserve ordering, so all non-q parameters are treated as media type
+			// parameters.
+			for key, val := range params {
+				if key == "q" {
+					if q, err := strconv.ParseFloat(val, 64); err == nil && q >= 0 && q <= 1 {
+						rng.quality = q
+					}
+					continue
+				}
+
+				if rng.params == nil {
+					rng.params = make(map[string]string, len(params))
+				}
+
+				rng.params[key] = val
+			}
+
+			out = append(out, rng)
+		}
+	}
+
+	return out
+}
+
+// quality returns the quality value the client assigned to the given content
+// type, using the most specific matching media range.
+//
+// A return value of 0 means the content type is not acceptable.
+func (a acceptRanges) quality(contentType string) float64 {
+	if len(a) == 0 {
+		return 1
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0
+	}
+
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	best := -1
+	quality := 0.0
+
+	for _, rng := range a {
+		if rng.matches(typ, subtype, params) && rng.specificity() > best {
+			best = rng.specificity()
+			quality = rng.quality
+		}
+	}
+
+	return quality
+}
+
+// negotiateSerializer selects the ObjectSerializer that should be used to
+// serialize the given body based on the given Accept header values.
+//
+// Of the serializers that match the given body, the one whose content type has
+// the highest quality value is selected, with ties going to the serializer that
+// was registered first.  If none of the given serializers match the body, the
+// default serializer is considered instead.
+//
+// If none of the matching serializers produce an acceptable content type, the
+// returned serializer will be nil.
+func negotiateSerializer(accept []string, serializers []ObjectSerializer, body any) ObjectSerializer {
+	ranges := parseAccept(accept)
+
+	var selected ObjectSerializer
+	var quality float64
+	var matched bool
+
+	test := func(serializer ObjectSerializer) {
+		if q := ranges.quality(serializer.ContentType()); q > quality {
+			selected = serializer
+			quality = q
+		}
+	}
+
+	for _, serial := range serializers {
+		if serial.Matches(body) {
+			matched = true
+			test(serial)
+		}
+	}
+
+	if !matched {
+		test(defaultObjectSerializer{})
+	}
+
+	return selected
+}
+
+// addVary appends the given header name to the Vary header of the given
+// headers, unless it is already present.
+func addVary(headers http.Header, name string) {
+	for _, value := range headers.Values(HeaderVary) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token == "*" || strings.EqualFold(token, name) {
+				return
+			}
+		}
+	}
+
+	headers.Add(HeaderVary, name)
+}
+
+// negotiateEncoding selects the content coding that should be used to encode a
+// response based on the given Accept-Encoding header values, from the given
+// list of available codings in order of server preference.
+//
+// Of the available codings, the one with the highest quality value is
+// selected, with ties going to the coding listed first.  If the request did not
+// include an Accept-Encoding header, or none of the available codings are
+// acceptable, the returned string will be empty, meaning the response should
+// not be encoded.
+func negotiateEncoding(values []string, available []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+
+			if len(coding) == 0 {
+				continue
+			}
+
+			quality := 1.0
+			for _, param := range strings.Split(params, ";") {
+				if key, val, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.EqualFold(key, "q") {
+					if q, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && q >= 0 && q <= 1 {
+						quality = q
+					}
+				}
+			}
+
+			if coding == "*" {
+				wildcard = quality
+			} else {
+				qualities[coding] = quality
+			}
+		}
+	}
+
+	selected := ""
+	best := 0.0
+
+	for _, coding := range available {
+		quality, ok := qualities[strings.ToLower(coding)]
+		if !ok {
+			quality = max(wildcard, 0)
+		}
+
+		if quality > best {
+			selected = coding
+			best = quality
+		}
+	}
+
+	return selected
+}
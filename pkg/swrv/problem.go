@@ -0,0 +1,138 @@
+package swrv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Problem is an RFC 9457 problem details object, which may be used to describe
+// errors in HTTP API responses.
+//
+// Problem instances are serialized as "application/problem+json" documents.
+// Problem also implements the error interface, and will be converted into a
+// problem document response when returned from a RequestHandlerE or
+// RequestFilterE.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	//
+	// If empty, the problem type is assumed to be "about:blank".
+	Type string
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+
+	// Status is the HTTP status code for this occurrence of the problem.
+	Status int
+
+	// Detail is a human-readable explanation specific to this occurrence of the
+	// problem.
+	Detail string
+
+	// Instance is a URI reference that identifies the specific occurrence of
+	// the problem.
+	Instance string
+
+	// Extensions contains additional members that will be serialized alongside
+	// the standard problem members.
+	//
+	// Extension members that share a name with a standard member are ignored.
+	Extensions map[string]any
+}
+
+// NewProblem returns a new Problem instance with the given status code and
+// detail message, and a title set to the standard status text for the status
+// code.
+func NewProblem(code int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+	}
+}
+
+// NewProblemResponse returns a new Response with the given status code and an
+// "application/problem+json" body describing the given detail.
+func NewProblemResponse(code int, detail string) Response {
+	return NewProblem(code, detail).ToResponse()
+}
+
+// WithType sets the problem type URI on this Problem.
+func (p *Problem) WithType(uri string) *Problem {
+	p.Type = uri
+	return p
+}
+
+// WithInstance sets the problem instance URI on this Problem.
+func (p *Problem) WithInstance(uri string) *Problem {
+	p.Instance = uri
+	return p
+}
+
+// WithExtension sets the given extension member on this Problem.
+func (p *Problem) WithExtension(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any, 1)
+	}
+
+	p.Extensions[key] = value
+	return p
+}
+
+// ToResponse returns a new Response with this Problem's status code, and this
+// Problem serialized as the response body.
+//
+// The response body is pre-serialized, and so will not be passed through any
+// ObjectSerializer.
+func (p *Problem) ToResponse() Response {
+	body, err := json.Marshal(p)
+
+	// Fall back to the standard members alone if an extension member could not
+	// be serialized.
+	if err != nil {
+		body, _ = json.Marshal(&Problem{p.Type, p.Title, p.Status, p.Detail, p.Instance, nil})
+	}
+
+	code := p.Status
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+
+	return NewResponse().
+		WithCode(code).
+		WithHeader(HeaderContentType, ContentTypeApplicationProblemJSON).
+		WithBody(bytes.NewReader(body))
+}
+
+func (p *Problem) Error() string {
+	if len(p.Detail) > 0 {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(p.Extensions)+5)
+
+	for key, value := range p.Extensions {
+		out[key] = value
+	}
+
+	setOrDelete := func(key string, value any, set bool) {
+		if set {
+			out[key] = value
+		} else {
+			delete(out, key)
+		}
+	}
+
+	setOrDelete("type", p.Type, len(p.Type) > 0)
+	setOrDelete("title", p.Title, len(p.Title) > 0)
+	setOrDelete("status", p.Status, p.Status != 0)
+	setOrDelete("detail", p.Detail, len(p.Detail) > 0)
+	setOrDelete("instance", p.Instance, len(p.Instance) > 0)
+
+	return json.Marshal(out)
+}
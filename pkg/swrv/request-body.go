@@ -0,0 +1,176 @@
+package swrv
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrRequestBodyTooLarge is returned when reading a request body that exceeds
+// the maximum body size configured for the Server or ControllerSpec.
+//
+// When a request body exceeds the maximum size, the Server will respond to the
+// request with a 413 Content Too Large error regardless of the Response
+// returned by the RequestHandler.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+// DefaultMaxDecodedBodySize is the maximum decompressed size, in bytes, of a
+// compressed request body when no maximum body size has been configured for
+// the Server or ControllerSpec.
+const DefaultMaxDecodedBodySize = 32 << 20
+
+// supportedRequestEncodings lists the content codings that may be used for
+// request bodies, as advertised in the Accept-Encoding header of 415 responses.
+const supportedRequestEncodings = "gzip, deflate"
+
+// prepareBody wraps the given request's body to enforce the given maximum body
+// size and to transparently decode compressed bodies.
+//
+// If the request body cannot be accepted, false is returned along with the
+// error response that should be sent.  A nil response indicates that the body
+// is too large.
+func (c controller) prepareBody(request *request, maxSize int64) (Response, bool) {
+	raw := request.request
+
+	if raw.Body == nil || raw.Body == http.NoBody {
+		return nil, true
+	}
+
+	if maxSize > 0 && raw.ContentLength > maxSize {
+		c.logger.Debug("request content length exceeds the maximum body size", "content-length", raw.ContentLength, "max-body-size", maxSize)
+		request.bodyTooLarge = true
+		raw.Body = http.NoBody
+		return nil, false
+	}
+
+	var body io.Reader = raw.Body
+
+	if maxSize > 0 {
+		body = &limitedBody{reader: body, remaining: maxSize, request: request}
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(raw.Header.Get(HeaderContentEncoding)))
+
+	switch encoding {
+	case "", "identity":
+		if maxSize <= 0 {
+			return nil, true
+		}
+
+	case "gzip", "x-gzip", "deflate":
+		decodedMax := maxSize
+		if decodedMax <= 0 {
+			decodedMax = DefaultMaxDecodedBodySize
+		}
+
+		body = &limitedBody{reader: &decodedBody{encoding: encoding, source: body}, remaining: decodedMax, request: request}
+
+		// The body handlers see is no longer encoded, and its length is unknown.
+		raw.Header.Del(HeaderContentEncoding)
+		raw.Header.Del(HeaderContentLength)
+		raw.ContentLength = -1
+
+	default:
+		c.logger.Debug("unsupported request content encoding, returning 415 error", "content-encoding", encoding)
+
+		return c.errHandlers.errorResponse(415, fmt.Sprintf("unsupported request content encoding %q", encoding)).
+			WithHeader(HeaderAcceptEncoding, supportedRequestEncodings), false
+	}
+
+	// The original body is closed by the controller once the request has been
+	// handled.
+	raw.Body = io.NopCloser(body)
+
+	return nil, true
+}
+
+// limitedBody wraps a request body to fail with ErrRequestBodyTooLarge once
+// more than a set number of bytes have been read.
+type limitedBody struct {
+	reader    io.Reader
+	remaining int64
+	request   *request
+	err       error
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	// Read one byte beyond the limit so that a body of exactly the maximum size
+	// is not mistaken for one that exceeds it.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(p)
+
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(l.remaining)
+	l.remaining = 0
+	l.err = ErrRequestBodyTooLarge
+	l.request.bodyTooLarge = true
+
+	return n, l.err
+}
+
+// decodedBody lazily decodes a request body compressed with the gzip or deflate
+// content coding.
+type decodedBody struct {
+	encoding string
+	source   io.Reader
+	decoder  io.Reader
+	err      error
+}
+
+func (d *decodedBody) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	if d.decoder == nil {
+		var err error
+
+		if d.encoding == "deflate" {
+			d.decoder, err = zlib.NewReader(d.source)
+		} else {
+			d.decoder, err = gzip.NewReader(d.source)
+		}
+
+		if err != nil {
+			d.err = d.wrap(err)
+			return 0, d.err
+		}
+	}
+
+	n, err := d.decoder.Read(p)
+	if err != nil && err != io.EOF {
+		d.err = d.wrap(err)
+		return n, d.err
+	}
+
+	return n, err
+}
+
+// wrap converts an error raised while decoding the body into a 400 Bad Request
+// HTTPError, unless it was caused by the body exceeding its maximum size.
+func (d *decodedBody) wrap(err error) error {
+	if errors.Is(err, ErrRequestBodyTooLarge) {
+		return err
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("malformed %s request body", d.encoding), err)
+}
@@ -1,5 +1,10 @@
 package swrv
 
+import (
+	"context"
+	"reflect"
+)
+
 // RequestContext is a map of arbitrary state that may be attached to a Request
 // instance as it passes through the various stages of the request handling
 // process.
@@ -43,3 +48,67 @@ func (r requestContext) Len() int {
 func (r requestContext) IsEmpty() bool {
 	return len(r) == 0
 }
+
+// RequestContextKey is the type used to look up RequestContext entries through
+// a standard context.Context.
+//
+// Values stored in a Request's RequestContext are visible through the
+// Request's context.Context when looked up using a RequestContextKey.
+//
+// Example:
+//
+//	request.AdditionalContext().Put("user", user)
+//	...
+//	user := ctx.Value(swrv.RequestContextKey("user"))
+type RequestContextKey string
+
+// RequestContextFrom returns the RequestContext bridged into the given
+// context.Context, if any.
+//
+// If the given context was not derived from a Request's context, the returned
+// value will be nil.
+func RequestContextFrom(ctx context.Context) RequestContext {
+	if values, ok := ctx.Value(requestContextBridgeKey{}).(requestContext); ok {
+		return values
+	}
+
+	return nil
+}
+
+type requestContextBridgeKey struct{}
+
+// bridgedContext exposes the entries of a RequestContext through the
+// context.Context value lookup chain.
+type bridgedContext struct {
+	context.Context
+	values requestContext
+}
+
+func bridgeContext(ctx context.Context, values requestContext) context.Context {
+	// If the context is already bridged to the target RequestContext (for
+	// example, it was derived from the Request's own context), there is no need
+	// to wrap it again.
+	if existing, ok := ctx.Value(requestContextBridgeKey{}).(requestContext); ok && sameRequestContext(existing, values) {
+		return ctx
+	}
+
+	return bridgedContext{ctx, values}
+}
+
+func (b bridgedContext) Value(key any) any {
+	switch k := key.(type) {
+	case requestContextBridgeKey:
+		return b.values
+	case RequestContextKey:
+		if val, ok := b.values[string(k)]; ok {
+			return val
+		}
+	}
+
+	return b.Context.Value(key)
+}
+
+func sameRequestContext(a, b requestContext) bool {
+	// Maps are not comparable, so compare the underlying map pointers instead.
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
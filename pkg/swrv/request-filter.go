@@ -29,3 +29,61 @@ type RequestFilterFunc func(request Request) Response
 func (r RequestFilterFunc) FilterRequest(request Request) Response {
 	return r(request)
 }
+
+// RequestFilterE is a variant of RequestFilter that may return an error to halt
+// processing of the request.
+//
+// Errors returned by a RequestFilterE are converted into a Response by the
+// Server's ErrorMapper instances, and that Response is then passed through the
+// controller's ResponseFilters as usual.
+//
+// As filters are registered as RequestFilter values, custom RequestFilterE
+// implementations must be wrapped with WrapRequestFilterE, or implemented as a
+// RequestFilterEFunc.
+type RequestFilterE interface {
+
+	// FilterRequestE may apply changes to the incoming Request's
+	// RequestContext, or optionally, halt processing of the Request by returning
+	// a non-nil Response or a non-nil error.
+	//
+	// If a non-nil error is returned, the returned Response will be ignored.
+	FilterRequestE(request Request) (Response, error)
+}
+
+// RequestFilterEFunc defines a function that implements the RequestFilterE
+// interface.
+//
+// RequestFilterEFunc also implements RequestFilter, and so may be registered
+// directly as a RequestFilter.  When called outside a Server controller through
+// the RequestFilter interface, errors are converted using the
+// DefaultErrorMapper.
+type RequestFilterEFunc func(request Request) (Response, error)
+
+func (r RequestFilterEFunc) FilterRequestE(request Request) (Response, error) {
+	return r(request)
+}
+
+func (r RequestFilterEFunc) FilterRequest(request Request) Response {
+	if response, err := r(request); err != nil {
+		return defaultMapError(request, err)
+	} else {
+		return response
+	}
+}
+
+// WrapRequestFilterE wraps the given RequestFilterE instance as a
+// RequestFilter that may be registered with a Server, ControllerGroup, or
+// ControllerSpec.
+func WrapRequestFilterE(filter RequestFilterE) RequestFilter {
+	return RequestFilterEFunc(filter.FilterRequestE)
+}
+
+// callRequestFilter calls the given filter, using the error returning variant
+// if the filter implements RequestFilterE.
+func callRequestFilter(filter RequestFilter, request Request) (Response, error) {
+	if f, ok := filter.(RequestFilterE); ok {
+		return f.FilterRequestE(request)
+	}
+
+	return filter.FilterRequest(request), nil
+}
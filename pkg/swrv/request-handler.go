@@ -16,3 +16,59 @@ type RequestHandlerFunc func(request Request) Response
 func (r RequestHandlerFunc) HandleRequest(request Request) Response {
 	return r(request)
 }
+
+// RequestHandlerE is a variant of RequestHandler that may return an error in
+// place of a Response.
+//
+// Errors returned by a RequestHandlerE are converted into a Response by the
+// Server's ErrorMapper instances, and that Response is then passed through the
+// controller's ResponseFilters as usual.
+//
+// As ControllerSpec instances only accept RequestHandler values, custom
+// RequestHandlerE implementations must be wrapped with WrapRequestHandlerE,
+// or implemented as a RequestHandlerEFunc.
+type RequestHandlerE interface {
+
+	// HandleRequestE is called to process incoming requests and transform them
+	// into Response objects to be returned to the HTTP client caller.
+	//
+	// If a non-nil error is returned, the returned Response will be ignored.
+	HandleRequestE(request Request) (Response, error)
+}
+
+// A RequestHandlerEFunc is a function that implements the RequestHandlerE
+// interface.
+//
+// RequestHandlerEFunc also implements RequestHandler, and so may be passed
+// directly to NewController.  When called outside a Server controller through
+// the RequestHandler interface, errors are converted using the
+// DefaultErrorMapper.
+type RequestHandlerEFunc func(request Request) (Response, error)
+
+func (r RequestHandlerEFunc) HandleRequestE(request Request) (Response, error) {
+	return r(request)
+}
+
+func (r RequestHandlerEFunc) HandleRequest(request Request) Response {
+	if response, err := r(request); err != nil {
+		return defaultMapError(request, err)
+	} else {
+		return response
+	}
+}
+
+// WrapRequestHandlerE wraps the given RequestHandlerE instance as a
+// RequestHandler that may be passed to NewController.
+func WrapRequestHandlerE(handler RequestHandlerE) RequestHandler {
+	return RequestHandlerEFunc(handler.HandleRequestE)
+}
+
+// callRequestHandler calls the given handler, using the error returning variant
+// if the handler implements RequestHandlerE.
+func callRequestHandler(handler RequestHandler, request Request) (Response, error) {
+	if h, ok := handler.(RequestHandlerE); ok {
+		return h.HandleRequestE(request)
+	}
+
+	return handler.HandleRequest(request), nil
+}
@@ -0,0 +1,51 @@
+package swrv
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// maxRequestIDLength is the maximum length of a client provided request ID that
+// will be accepted.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key under which the ID of a request wrapped by a
+// controller is stored, so that error controllers called for the same request
+// reuse the same ID.
+type requestIDKey struct{}
+
+// requestID returns the ID previously assigned to the given request, the
+// request ID sent by the client in the X-Request-ID header, or a new random
+// request ID if the client did not send a usable one.
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+
+	if id := r.Header.Get(HeaderXRequestID); isValidRequestID(id) {
+		return id
+	}
+
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+
+	return hex.EncodeToString(buf[:])
+}
+
+// isValidRequestID tests whether the given client provided request ID is
+// non-empty, of a reasonable length, and contains only printable ASCII
+// characters, so that it may be safely included in log output.
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7E {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,26 +1,57 @@
 package swrv
 
 import (
+	"context"
+	"crypto/x509"
+	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
-
-	"github.com/gorilla/mux"
 )
 
 // WrapRequest wraps the given http.Request pointer in a new Request instance.
 //
 // The new Request will have an empty RequestContext attached.
+//
+// The wrapped http.Request's context.Context will be bridged to the new
+// RequestContext.
+//
+// URI params on the new Request will be resolved using http.Request.PathValue,
+// and the new Request's Logger will log through the default log/slog logger.
 func WrapRequest(r *http.Request) Request {
+	return wrapRequest(r, nil, standardPathParams{}, NewSlogLogger(nil))
+}
+
+func wrapRequest(r *http.Request, deserializers []ObjectDeserializer, params pathParamSource, logger Logger) *request {
+	values := make(requestContext, 2)
+	id := requestID(r)
+
 	return &request{
-		request: r,
-		context: make(requestContext, 2),
+		request:       r.WithContext(bridgeContext(context.WithValue(r.Context(), requestIDKey{}, id), values)),
+		context:       values,
+		deserializers: deserializers,
+		params:        params,
+		id:            id,
+		logger:        logger.With("method", r.Method, "path", r.URL.Path, "request-id", id),
 	}
 }
 
 type request struct {
-	request *http.Request
-	context requestContext
+	request       *http.Request
+	context       requestContext
+	deserializers []ObjectDeserializer
+	params        pathParamSource
+	id            string
+	logger        Logger
+
+	// unsupportedMedia is set when ReadBodyInto fails to find an
+	// ObjectDeserializer for the request's Content-Type.
+	unsupportedMedia bool
+
+	// bodyTooLarge is set when the request body exceeds the maximum body size
+	// configured for the controller.
+	bodyTooLarge bool
 }
 
 func (r *request) Raw() *http.Request {
@@ -31,10 +62,27 @@ func (r *request) Method() string {
 	return r.request.Method
 }
 
+func (r *request) RequestID() string {
+	return r.id
+}
+
+func (r *request) Logger() Logger {
+	return r.logger
+}
+
 func (r *request) AdditionalContext() RequestContext {
 	return r.context
 }
 
+func (r *request) Context() context.Context {
+	return r.request.Context()
+}
+
+func (r *request) WithContext(ctx context.Context) Request {
+	r.request = r.request.WithContext(bridgeContext(ctx, r.context))
+	return r
+}
+
 // Body ////////////////////////////////////////////////////////////////////////
 
 func (r *request) Body() io.ReadCloser {
@@ -46,10 +94,49 @@ func (r *request) WithBody(fn func(io.Reader)) {
 	fn(r.request.Body)
 }
 
+func (r *request) ReadBodyInto(target any) error {
+	contentType := r.request.Header.Get(HeaderContentType)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err == nil {
+		for _, deserializer := range r.deserializers {
+			if deserializer.Matches(mediaType) {
+				return deserializer.Deserialize(r.request.Body, target)
+			}
+		}
+
+		if defaultObjectDeserializer.Matches(mediaType) {
+			return defaultObjectDeserializer.Deserialize(r.request.Body, target)
+		}
+	}
+
+	r.unsupportedMedia = true
+
+	return fmt.Errorf("%w: %q", ErrUnsupportedMediaType, contentType)
+}
+
 func (r *request) MultipartReader() (*multipart.Reader, error) {
 	return r.request.MultipartReader()
 }
 
+// TLS /////////////////////////////////////////////////////////////////////////
+
+func (r *request) IsTLS() bool {
+	return r.request.TLS != nil
+}
+
+func (r *request) PeerCertificate() *x509.Certificate {
+	if r.request.TLS == nil || len(r.request.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+
+	if chain := r.request.TLS.VerifiedChains[0]; len(chain) > 0 {
+		return chain[0]
+	}
+
+	return nil
+}
+
 // Query Params ////////////////////////////////////////////////////////////////
 
 func (r *request) HasQueryParam(name string) bool {
@@ -95,9 +182,9 @@ func (r *request) GetCookies() []*http.Cookie {
 // URI Params //////////////////////////////////////////////////////////////////
 
 func (r *request) URIParam(name string) string {
-	return mux.Vars(r.request)[name]
+	return r.params.PathParam(r.request, name)
 }
 
 func (r *request) URIParams() map[string]string {
-	return mux.Vars(r.request)
+	return r.params.PathParams(r.request)
 }
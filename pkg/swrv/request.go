@@ -1,6 +1,8 @@
 package swrv
 
 import (
+	"context"
+	"crypto/x509"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -15,13 +17,48 @@ type Request interface {
 	// Method returns the HTTP request method used.
 	Method() string
 
+	// RequestID returns the identifier used to correlate log messages for this
+	// request.
+	//
+	// If the client sent an X-Request-ID header with a reasonable value, that
+	// value is used as the request ID, otherwise a random ID is generated.
+	RequestID() string
+
+	// Logger returns a Logger scoped to this request.
+	//
+	// Messages logged through the returned Logger include the request's method,
+	// path, and request ID, as well as the path of the controller handling the
+	// request.
+	Logger() Logger
+
 	// AdditionalContext returns the RequestContext object attached to this
 	// request.
 	//
 	// Additional context may be used to store arbitrary data along with the
 	// incoming request.
+	//
+	// Entries in the RequestContext are also visible through the request's
+	// context.Context when looked up with a RequestContextKey.
 	AdditionalContext() RequestContext
 
+	// Context returns the request's context.Context.
+	//
+	// The returned context is cancelled when the client's connection closes or
+	// the request is otherwise completed, and carries any deadlines or values
+	// applied by previous RequestFilters via WithContext.
+	Context() context.Context
+
+	// WithContext replaces the request's context.Context with the given context.
+	//
+	// This is typically used by RequestFilters to derive a new context from the
+	// current one, for example to apply a timeout or attach values, that will be
+	// visible to subsequent filters, the RequestHandler, and ResponseFilters.
+	//
+	// The given context will remain bridged to the request's RequestContext.
+	//
+	// Passing a nil context will cause a panic.
+	WithContext(ctx context.Context) Request
+
 	// GetHeader returns the first value associated with the given header name.
 	//
 	// If no such header was found on the request, the returned string will be
@@ -66,7 +103,7 @@ type Request interface {
 	// controller.
 	URIParam(name string) string
 
-	// URIParams returns
+	// URIParams returns all the URI params for the request, keyed by name.
 	URIParams() map[string]string
 
 	// Body returns an io.ReadCloser over the raw request body.
@@ -78,6 +115,30 @@ type Request interface {
 	// function.
 	WithBody(fn func(reader io.Reader))
 
+	// IsTLS tests whether this request was received over a TLS connection.
+	IsTLS() bool
+
+	// PeerCertificate returns the verified certificate presented by the client
+	// during the TLS handshake.
+	//
+	// If the request was not received over TLS, or the client certificate was
+	// not verified against the Server's client certificate authorities, the
+	// returned value will be nil.
+	PeerCertificate() *x509.Certificate
+
+	// ReadBodyInto deserializes the request body into the given target value
+	// using the first ObjectDeserializer registered with the Server that matches
+	// the request's Content-Type.
+	//
+	// If no registered ObjectDeserializer matches the request's Content-Type,
+	// this method returns ErrUnsupportedMediaType and the Server will respond to
+	// the request with a 415 Unsupported Media Type error regardless of the
+	// Response returned by the RequestHandler.
+	//
+	// The request body will be automatically closed once the request has been
+	// handled.
+	ReadBodyInto(target any) error
+
 	// MultipartReader returns a MIME multipart reader if this is a
 	// multipart/form-data or a multipart/mixed POST request, else returns nil and
 	// an error.
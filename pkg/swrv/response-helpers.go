@@ -1,7 +1,36 @@
 package swrv
 
-import "strings"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
-func newEmptyResponseError(error string) Response {
-	return NewResponse().WithCode(500).WithBody(strings.NewReader(error))
+// newStatusTextResponse builds a plain-text Response with the given status code
+// whose body is the standard status text for that code.
+func newStatusTextResponse(code int) Response {
+	return newErrorResponse(code, http.StatusText(code))
+}
+
+func newErrorResponse(code int, detail string) Response {
+	return NewResponse().WithCode(code).WithBody(strings.NewReader(detail))
+}
+
+// newFrameworkError builds the Response for an error generated by the
+// framework itself, either as a plain-text response or as a problem document.
+func newFrameworkError(problems bool, code int, detail string) Response {
+	if problems {
+		// Avoid repeating the title as the detail of the problem document.
+		if detail == http.StatusText(code) {
+			detail = ""
+		}
+
+		return NewProblemResponse(code, detail)
+	}
+
+	return newErrorResponse(code, detail)
+}
+
+func newUnsupportedMediaTypeError(problems bool, contentType string) Response {
+	return newFrameworkError(problems, 415, fmt.Sprintf("unsupported request content type %q", contentType))
 }
@@ -0,0 +1,47 @@
+package swrv
+
+import (
+	"io"
+	"net/http"
+)
+
+// trackingWriter wraps an http.ResponseWriter to keep track of whether the
+// response status has been written.
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (t *trackingWriter) WriteHeader(code int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *trackingWriter) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(b)
+}
+
+// ReadFrom preserves the io.ReaderFrom optimization of the wrapped writer, if
+// it has one.
+func (t *trackingWriter) ReadFrom(reader io.Reader) (int64, error) {
+	t.wroteHeader = true
+
+	if rf, ok := t.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(reader)
+	}
+
+	return io.Copy(writerOnly{t.ResponseWriter}, reader)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for use by
+// http.ResponseController.
+func (t *trackingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// writerOnly hides any optional interfaces implemented by the wrapped writer to
+// prevent io.Copy from recursing back into ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
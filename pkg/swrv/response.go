@@ -28,6 +28,21 @@ type Response interface {
 	// the server after the response has been written to the client.
 	WithBody(body any) Response
 
+	// WithBodyWriter sets a function that will write the response body directly
+	// to the client, replacing any body previously set on this Response.
+	//
+	// The function is called once the Response has passed through the response
+	// filters, and may write and flush partial output using the given
+	// StreamWriter.  The response status and headers are sent on the first
+	// write or flush.
+	//
+	// If the function returns an error before anything has been written, the
+	// error is converted into a response by the Server's ErrorMapper as if it
+	// had been returned by a RequestHandlerE.  If the function returns an error
+	// after the response has been started, the error is logged and the
+	// connection is aborted so that the client sees an incomplete response.
+	WithBodyWriter(fn func(writer StreamWriter) error) Response
+
 	// GetHeaders returns the ResponseHeaders attached to this Response instance.
 	GetHeaders() ResponseHeaders
 
@@ -36,6 +51,9 @@ type Response interface {
 
 	// OnComplete sets a callback function that will be called after the request
 	// processing is complete and the response has been sent to the HTTP client.
+	//
+	// Any response data buffered by the server is flushed to the client before
+	// the callback is called.
 	OnComplete(fn func()) Response
 
 	// GetOnComplete returns the OnComplete function, if one is present.
@@ -79,6 +97,11 @@ func (r *response) WithBody(body any) Response {
 	return r
 }
 
+func (r *response) WithBodyWriter(fn func(writer StreamWriter) error) Response {
+	r.body = bodyWriter(fn)
+	return r
+}
+
 func (r *response) GetHeaders() ResponseHeaders {
 	return r.headers
 }
@@ -0,0 +1,105 @@
+package swrv
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routePath records the controllers registered for a single route path
+// template while a Server is being built, so that HEAD, OPTIONS, and CORS
+// preflight requests for the path can be answered using the methods that were
+// actually registered for it.
+type routePath struct {
+	// router is the Router that the path's controllers were registered with.
+	router Router
+
+	// path is the path template relative to router.
+	path string
+
+	// template is the full path template.
+	template string
+
+	// methods holds every method registered for the path, in registration
+	// order.
+	methods []string
+
+	// any is set if a controller was registered for the path without any
+	// methods, meaning it accepts every method.
+	any bool
+
+	routes []routeEntry
+}
+
+// add records the given route registered for the path.
+func (p *routePath) add(route Route, handler http.Handler) {
+	p.routes = append(p.routes, routeEntry{route, handler})
+
+	if len(route.Methods) == 0 {
+		p.any = true
+		return
+	}
+
+	for _, method := range route.Methods {
+		if !containsFold(p.methods, method) {
+			p.methods = append(p.methods, method)
+		}
+	}
+}
+
+// allowsMethod tests whether a controller has been registered for the path
+// that accepts the given method.
+func (p *routePath) allowsMethod(method string) bool {
+	return p.any || containsFold(p.methods, method)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// optionsHandler answers OPTIONS requests for a path that has no controller
+// registered for the OPTIONS method, listing the path's methods in the Allow
+// header.
+type optionsHandler struct {
+	path *routePath
+}
+
+func (o optionsHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(HeaderAllow, strings.Join(o.path.methods, ", "))
+	w.Header().Set(HeaderContentLength, "0")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// headHandler answers HEAD requests by calling a GET controller and discarding
+// any response body it writes.
+//
+// Controllers do not read reader, file, or stream bodies for HEAD requests, so
+// the body is never produced just to be discarded.  The Content-Length header
+// is set by the controller when the length of the body is known without
+// producing it, as it is for serialized objects, in-memory readers, and files,
+// and is otherwise omitted.
+type headHandler struct {
+	handler http.Handler
+}
+
+func (h headHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(headWriter{w}, r)
+}
+
+// headWriter wraps an http.ResponseWriter to discard the response body.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (h headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for use by
+// http.ResponseController.
+func (h headWriter) Unwrap() http.ResponseWriter {
+	return h.ResponseWriter
+}
+
+// bodyAllowed tests whether a response with the given status code may include
+// a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
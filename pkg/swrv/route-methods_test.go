@@ -0,0 +1,111 @@
+package swrv_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+// trackedReader is a body reader that records whether it was read or closed.
+type trackedReader struct {
+	reader io.Reader
+	read   bool
+	closed bool
+}
+
+func (t *trackedReader) Read(b []byte) (int, error) {
+	t.read = true
+	return t.reader.Read(b)
+}
+
+func (t *trackedReader) Close() error {
+	t.closed = true
+	return nil
+}
+
+func TestAutoHeadDoesNotProduceBody(t *testing.T) {
+	var reader *trackedReader
+	streamed := false
+
+	server := swrv.NewServer("", 0).
+		WithControllers(
+			swrv.NewController("/reader", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+				reader = &trackedReader{reader: strings.NewReader("large export")}
+				return swrv.NewResponse().WithBody(reader)
+			})).ForMethods(http.MethodGet),
+			swrv.NewController("/stream", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+				return swrv.NewResponse().WithBodyWriter(func(writer swrv.StreamWriter) error {
+					streamed = true
+					_, err := writer.Write([]byte("streamed"))
+					return err
+				})
+			})).ForMethods(http.MethodGet),
+		)
+
+	client := swrvtest.New(server)
+
+	client.HEAD("/reader").Expect(t).
+		Status(http.StatusOK).
+		Body("")
+
+	if reader.read || !reader.closed {
+		t.Errorf("expected the reader to be closed without being read, read %t closed %t", reader.read, reader.closed)
+	}
+
+	client.HEAD("/stream").Expect(t).
+		Status(http.StatusOK).
+		Body("")
+
+	if streamed {
+		t.Error("expected the body writer not to be called for a HEAD request")
+	}
+}
+
+func TestAutoHeadContentLength(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithControllers(swrv.NewController("/hello", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewResponse().WithBody("hello")
+		})).ForMethods(http.MethodGet))
+
+	client := swrvtest.New(server)
+
+	client.GET("/hello").Expect(t).
+		Header(swrv.HeaderContentLength, "5").
+		Body("hello")
+
+	client.HEAD("/hello").Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentLength, "5").
+		Body("")
+}
+
+func TestAutoHeadMatchesCompressedGet(t *testing.T) {
+	client := compressedStaticClient()
+
+	get := client.GET("/static/site.css").Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentEncoding, "gzip").
+		Recorder().Header()
+
+	head := client.HEAD("/static/site.css").Expect(t).
+		Status(http.StatusOK).
+		Body("").
+		Recorder().Header()
+
+	for _, header := range []string{
+		swrv.HeaderContentEncoding,
+		swrv.HeaderContentLength,
+		swrv.HeaderContentType,
+		swrv.HeaderETag,
+		swrv.HeaderVary,
+		swrv.HeaderAcceptRanges,
+	} {
+		if get.Get(header) != head.Get(header) {
+			t.Errorf("expected HEAD %s header %q to match GET %q", header, head.Get(header), get.Get(header))
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package swrv
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NewRadixRouter returns a new Router backed by swrv's built-in radix tree
+// router.
+//
+// Route paths use the same wildcard syntax as NewServeMuxRouter: a path
+// segment of the form "{name}" matches any single non-empty path segment, and
+// a final path segment of the form "{name...}" matches the remainder of the
+// path.  Wildcards must make up an entire path segment.
+//
+// When more than one route could match a request path, static segments take
+// precedence over single segment wildcards, which take precedence over
+// catch-all wildcards.
+//
+// Routes are validated as they are registered, and an error is returned for
+// routes that are malformed, that use a different wildcard name than a
+// previously registered route in the same position, or that would match the
+// same requests as a previously registered route.
+//
+// Routing a request does not allocate.  The only change made to the routed
+// request is that its Pattern field is set to the matched route path.  Path
+// parameters are not recorded on the request; instead, PathParam looks up the
+// route by the request's Pattern and reads the parameter from the request's
+// URL path when it is called.  As a result, path parameters are not available
+// via http.Request.PathValue, and handlers that rewrite the request's URL path
+// must do so on a copy of the request.
+func NewRadixRouter() Router {
+	return &radixRouter{
+		root:      new(radixNode),
+		templates: make(map[string]*radixNode),
+		notFound:  http.NotFoundHandler(),
+		notAllowed: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}),
+	}
+}
+
+type radixRouter struct {
+	root *radixNode
+
+	// templates holds the node at which each registered route path template
+	// ends, for resolving the path parameters of routed requests.
+	templates map[string]*radixNode
+
+	notFound   http.Handler
+	notAllowed http.Handler
+}
+
+func (r *radixRouter) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	node := r.root.match(request.URL.Path)
+	if node == nil {
+		r.notFound.ServeHTTP(w, request)
+		return
+	}
+
+	request.Pattern = node.template
+
+	node.routes.serve(w, request, r.notFound, r.notAllowed)
+}
+
+func (r *radixRouter) Handle(route Route, handler http.Handler) error {
+	return r.handle("", route, handler)
+}
+
+func (r *radixRouter) Group(prefix string) Router {
+	return radixGroup{root: r, prefix: prefix}
+}
+
+func (r *radixRouter) NotFoundHandler(handler http.Handler) {
+	r.notFound = handler
+}
+
+func (r *radixRouter) MethodNotAllowedHandler(handler http.Handler) {
+	r.notAllowed = handler
+}
+
+func (r *radixRouter) PathParam(request *http.Request, name string) string {
+	if node, ok := r.templates[request.Pattern]; ok {
+		for i := range node.params {
+			if node.params[i].name == name {
+				return node.params[i].value(request.URL.Path)
+			}
+		}
+	}
+
+	return ""
+}
+
+func (r *radixRouter) PathParams(request *http.Request) map[string]string {
+	out := make(map[string]string)
+
+	if node, ok := r.templates[request.Pattern]; ok {
+		for i := range node.params {
+			out[node.params[i].name] = node.params[i].value(request.URL.Path)
+		}
+	}
+
+	return out
+}
+
+func (r *radixRouter) handle(prefix string, route Route, handler http.Handler) error {
+	template := prefix + route.Path
+
+	if len(template) == 0 {
+		return fmt.Errorf("invalid route: empty path")
+	}
+
+	if template[0] != '/' {
+		return fmt.Errorf("invalid route %q: path must begin with a slash", template)
+	}
+
+	if err := checkGorillaTemplate(template); err != nil {
+		return err
+	}
+
+	tokens, params, err := parseRadixTemplate(template)
+	if err != nil {
+		return err
+	}
+
+	node, err := r.root.insert(template, tokens)
+	if err != nil {
+		return err
+	}
+
+	if node.template == "" {
+		node.template = template
+		node.params = params
+		r.templates[template] = node
+	}
+
+	return node.routes.add(template, route, handler)
+}
+
+// radixGroup is a view of a radixRouter that registers routes under a path
+// prefix.
+type radixGroup struct {
+	root   *radixRouter
+	prefix string
+}
+
+func (r radixGroup) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.root.ServeHTTP(w, request)
+}
+
+func (r radixGroup) Handle(route Route, handler http.Handler) error {
+	return r.root.handle(r.prefix, route, handler)
+}
+
+func (r radixGroup) Group(prefix string) Router {
+	return radixGroup{root: r.root, prefix: r.prefix + prefix}
+}
+
+func (r radixGroup) NotFoundHandler(handler http.Handler) {
+	r.root.NotFoundHandler(handler)
+}
+
+func (r radixGroup) MethodNotAllowedHandler(handler http.Handler) {
+	r.root.MethodNotAllowedHandler(handler)
+}
+
+func (r radixGroup) PathParam(request *http.Request, name string) string {
+	return r.root.PathParam(request, name)
+}
+
+func (r radixGroup) PathParams(request *http.Request) map[string]string {
+	return r.root.PathParams(request)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type radixTokenKind uint8
+
+const (
+	radixStaticToken radixTokenKind = iota
+	radixParamToken
+	radixCatchAllToken
+)
+
+// radixToken is a single piece of a parsed route path template: either a run
+// of static text or a wildcard.
+type radixToken struct {
+	kind  radixTokenKind
+	value string
+}
+
+// radixParam describes where the value of a path parameter may be found in a
+// request path matched by a route.
+type radixParam struct {
+	name string
+
+	// slashes is the number of slashes in the request path that precede the
+	// parameter's path segment.
+	slashes int
+
+	catchAll bool
+}
+
+// value returns the value of the parameter from the given request path.
+func (p radixParam) value(path string) string {
+	for i := 0; i < p.slashes; i++ {
+		path = path[strings.IndexByte(path, '/')+1:]
+	}
+
+	if !p.catchAll {
+		if end := strings.IndexByte(path, '/'); end >= 0 {
+			return path[:end]
+		}
+	}
+
+	return path
+}
+
+// parseRadixTemplate splits the given route path template into static text
+// and wildcard tokens, and records the position of each of the template's
+// path parameters.
+func parseRadixTemplate(template string) ([]radixToken, []radixParam, error) {
+	var tokens []radixToken
+	var params []radixParam
+
+	rest := template
+	slashes := 0
+
+	for len(rest) > 0 {
+		start := strings.IndexByte(rest, '{')
+
+		if start < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, nil, fmt.Errorf("invalid route %q: unmatched '}'", template)
+			}
+
+			tokens = append(tokens, radixToken{radixStaticToken, rest})
+			break
+		}
+
+		static := rest[:start]
+
+		if strings.IndexByte(static, '}') >= 0 {
+			return nil, nil, fmt.Errorf("invalid route %q: unmatched '}'", template)
+		}
+
+		if !strings.HasSuffix(static, "/") {
+			return nil, nil, fmt.Errorf("invalid route %q: wildcards must make up an entire path segment", template)
+		}
+
+		if len(static) > 0 {
+			tokens = append(tokens, radixToken{radixStaticToken, static})
+			slashes += strings.Count(static, "/")
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, nil, fmt.Errorf("invalid route %q: unmatched '{'", template)
+		}
+
+		name := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		if len(rest) > 0 && rest[0] != '/' {
+			return nil, nil, fmt.Errorf("invalid route %q: wildcards must make up an entire path segment", template)
+		}
+
+		kind := radixParamToken
+		if strings.HasSuffix(name, "...") {
+			kind = radixCatchAllToken
+			name = strings.TrimSuffix(name, "...")
+
+			if len(rest) > 0 {
+				return nil, nil, fmt.Errorf("invalid route %q: catch-all wildcard {%s...} must be at the end of the path", template, name)
+			}
+		}
+
+		if len(name) == 0 || strings.ContainsAny(name, "{/.") {
+			return nil, nil, fmt.Errorf("invalid route %q: invalid wildcard name %q", template, name)
+		}
+
+		for i := range params {
+			if params[i].name == name {
+				return nil, nil, fmt.Errorf("invalid route %q: duplicate wildcard name %q", template, name)
+			}
+		}
+
+		tokens = append(tokens, radixToken{kind, name})
+		params = append(params, radixParam{name: name, slashes: slashes, catchAll: kind == radixCatchAllToken})
+	}
+
+	return tokens, params, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// radixNode is a node in a radixRouter's route tree.
+//
+// Static nodes consume the text in their prefix, param nodes consume a single
+// non-empty path segment, and catch-all nodes consume the remainder of the
+// path.
+type radixNode struct {
+	prefix string
+
+	// indices holds the first byte of the prefix of each of the node's static
+	// children, in the same order as the static slice.
+	indices []byte
+	static  []*radixNode
+
+	param    *radixNode
+	catchAll *radixNode
+
+	// name is the wildcard name for param and catch-all nodes.
+	name string
+
+	// template is the full route path template for nodes at which a route
+	// ends.
+	template string
+	params   []radixParam
+	routes   routeSet
+}
+
+// insert adds the nodes needed for the given parsed template to the tree
+// rooted at this node, and returns the node at which the template ends.
+func (n *radixNode) insert(template string, tokens []radixToken) (*radixNode, error) {
+	for _, token := range tokens {
+		switch token.kind {
+		case radixStaticToken:
+			n = n.insertStatic(token.value)
+
+		case radixParamToken:
+			if n.param == nil {
+				n.param = &radixNode{name: token.value}
+			} else if n.param.name != token.value {
+				return nil, fmt.Errorf("invalid route %q: wildcard {%s} conflicts with wildcard {%s} in a previously registered route", template, token.value, n.param.name)
+			}
+
+			n = n.param
+
+		case radixCatchAllToken:
+			if n.catchAll == nil {
+				n.catchAll = &radixNode{name: token.value}
+			} else if n.catchAll.name != token.value {
+				return nil, fmt.Errorf("invalid route %q: wildcard {%s...} conflicts with wildcard {%s...} in a previously registered route", template, token.value, n.catchAll.name)
+			}
+
+			n = n.catchAll
+		}
+	}
+
+	return n, nil
+}
+
+// insertStatic adds the given static text below this node, splitting existing
+// nodes as necessary, and returns the node at which the text ends.
+func (n *radixNode) insertStatic(text string) *radixNode {
+	for len(text) > 0 {
+		i := indexByte(n.indices, text[0])
+
+		if i < 0 {
+			child := &radixNode{prefix: text}
+			n.indices = append(n.indices, text[0])
+			n.static = append(n.static, child)
+			return child
+		}
+
+		child := n.static[i]
+		common := commonPrefixLength(child.prefix, text)
+
+		// Split the child node if the text only shares part of its prefix.
+		if common < len(child.prefix) {
+			split := *child
+			split.prefix = child.prefix[common:]
+
+			*child = radixNode{
+				prefix:  child.prefix[:common],
+				indices: []byte{split.prefix[0]},
+				static:  []*radixNode{&split},
+			}
+		}
+
+		text = text[common:]
+		n = child
+	}
+
+	return n
+}
+
+// match returns the node for the route that matches the given remainder of a
+// request path, or nil if no route matches.
+func (n *radixNode) match(path string) *radixNode {
+	if len(path) == 0 {
+		if n.template != "" {
+			return n
+		}
+
+		if n.catchAll != nil {
+			return n.catchAll
+		}
+
+		return nil
+	}
+
+	if i := indexByte(n.indices, path[0]); i >= 0 {
+		child := n.static[i]
+
+		if strings.HasPrefix(path, child.prefix) {
+			if found := child.match(path[len(child.prefix):]); found != nil {
+				return found
+			}
+		}
+	}
+
+	if n.param != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+
+		if end > 0 {
+			if found := n.param.match(path[end:]); found != nil {
+				return found
+			}
+		}
+	}
+
+	if n.catchAll != nil {
+		return n.catchAll
+	}
+
+	return nil
+}
+
+func indexByte(bytes []byte, b byte) int {
+	for i := range bytes {
+		if bytes[i] == b {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func commonPrefixLength(a, b string) int {
+	i := 0
+
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
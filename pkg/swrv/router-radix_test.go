@@ -0,0 +1,149 @@
+package swrv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+// routeNamer returns a handler that writes the given name and the request's
+// path parameters, as resolved by the given router, so tests can tell which
+// route served a request.
+func routeNamer(router swrv.Router, name string, params ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out := name
+		for _, param := range params {
+			out += " " + param + "=" + router.PathParam(r, param)
+		}
+		_, _ = w.Write([]byte(out))
+	})
+}
+
+func TestRadixRouterConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []swrv.Route
+		route    swrv.Route
+	}{
+		{
+			name:     "same static path and method",
+			existing: []swrv.Route{{Path: "/users", Methods: []string{http.MethodGet}}},
+			route:    swrv.Route{Path: "/users", Methods: []string{http.MethodGet}},
+		},
+		{
+			name:     "any method overlaps a specific method",
+			existing: []swrv.Route{{Path: "/users", Methods: []string{http.MethodGet}}},
+			route:    swrv.Route{Path: "/users"},
+		},
+		{
+			name:     "different wildcard name in the same position",
+			existing: []swrv.Route{{Path: "/users/{id}"}},
+			route:    swrv.Route{Path: "/users/{name}/posts"},
+		},
+		{
+			name:     "different catch-all name in the same position",
+			existing: []swrv.Route{{Path: "/files/{path...}"}},
+			route:    swrv.Route{Path: "/files/{rest...}", Methods: []string{http.MethodPost}},
+		},
+		{name: "relative path", route: swrv.Route{Path: "users"}},
+		{name: "empty path", route: swrv.Route{Path: ""}},
+		{name: "partial segment wildcard", route: swrv.Route{Path: "/users/id-{id}"}},
+		{name: "unmatched brace", route: swrv.Route{Path: "/users/{id"}},
+		{name: "catch-all before the end", route: swrv.Route{Path: "/files/{path...}/meta"}},
+		{name: "duplicate wildcard name", route: swrv.Route{Path: "/users/{id}/posts/{id}"}},
+		{name: "gorilla regular expression wildcard", route: swrv.Route{Path: "/users/{id:[0-9]+}"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := swrv.NewRadixRouter()
+
+			for _, route := range test.existing {
+				if err := router.Handle(route, routeNamer(router, route.Path)); err != nil {
+					t.Fatalf("unexpected error registering %q: %s", route.Path, err)
+				}
+			}
+
+			if err := router.Handle(test.route, routeNamer(router, test.route.Path)); err == nil {
+				t.Errorf("expected an error registering %q %v", test.route.Path, test.route.Methods)
+			}
+		})
+	}
+}
+
+func TestRadixRouterAllowedOverlaps(t *testing.T) {
+	router := swrv.NewRadixRouter()
+
+	routes := []swrv.Route{
+		{Path: "/users", Methods: []string{http.MethodGet}},
+		{Path: "/users", Methods: []string{http.MethodPost}},
+		{Path: "/users/{id}"},
+		{Path: "/users/{id}/posts"},
+		{Path: "/users/me"},
+		{Path: "/users/{id}/{rest...}"},
+		{Path: "/reports", Headers: map[string]string{"X-Version": "2"}},
+		{Path: "/reports", Headers: map[string]string{"X-Version": "1"}},
+	}
+
+	for _, route := range routes {
+		if err := router.Handle(route, routeNamer(router, route.Path)); err != nil {
+			t.Errorf("unexpected error registering %q %v: %s", route.Path, route.Methods, err)
+		}
+	}
+}
+
+func TestRadixRouterPrecedence(t *testing.T) {
+	router := swrv.NewRadixRouter()
+
+	routes := map[string]http.Handler{
+		"/files/{path...}":       routeNamer(router, "catch-all", "path"),
+		"/files/{name}":          routeNamer(router, "param", "name"),
+		"/files/readme":          routeNamer(router, "static"),
+		"/files/{name}/versions": routeNamer(router, "param-static", "name"),
+		"/":                      routeNamer(router, "root"),
+	}
+
+	for path, handler := range routes {
+		if err := router.Handle(swrv.Route{Path: path, Methods: []string{http.MethodGet}}, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		code     int
+	}{
+		{"/files/readme", "static", http.StatusOK},
+		{"/files/notes", "param name=notes", http.StatusOK},
+		{"/files/readme/versions", "param-static name=readme", http.StatusOK},
+		{"/files/notes/2024/01", "catch-all path=notes/2024/01", http.StatusOK},
+		{"/files/readme/other", "catch-all path=readme/other", http.StatusOK},
+		{"/", "root", http.StatusOK},
+		{"/missing", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if recorder.Code != test.code {
+				t.Fatalf("expected status %d, got %d", test.code, recorder.Code)
+			}
+
+			if test.code == http.StatusOK && recorder.Body.String() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, recorder.Body.String())
+			}
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/files/readme", nil))
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for an unregistered method, got %d", recorder.Code)
+	}
+}
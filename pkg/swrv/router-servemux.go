@@ -0,0 +1,160 @@
+package swrv
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NewServeMuxRouter returns a new Router backed by the standard library's
+// pattern-based http.ServeMux.
+//
+// Route paths use the http.ServeMux wildcard syntax, for example "/users/{id}"
+// or "/files/{path...}".  Unlike a plain http.ServeMux, route paths ending in
+// a slash match only that exact path rather than every path beneath it.
+//
+// Method and header matching are performed by the Router itself, so routes
+// that share a path may be registered for different methods or headers.
+//
+// This is the Router used by a Server started without one.  Gorilla style
+// regular expression templates such as "/users/{id:[0-9]+}" are not supported,
+// and registering one returns an error directing the caller to the Router
+// returned by swrvgorilla.NewRouter.
+func NewServeMuxRouter() Router {
+	out := &serveMuxRouter{
+		mux:      http.NewServeMux(),
+		routes:   make(map[string]*serveMuxPath),
+		notFound: http.NotFoundHandler(),
+		notAllowed: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}),
+	}
+
+	return out
+}
+
+type serveMuxRouter struct {
+	mux        *http.ServeMux
+	routes     map[string]*serveMuxPath
+	notFound   http.Handler
+	notAllowed http.Handler
+}
+
+func (s *serveMuxRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requests that do not match any pattern are sent to the not found handler
+	// here rather than through a root "/" pattern, which would conflict with
+	// routes such as "/{path...}".
+	if _, pattern := s.mux.Handler(r); len(pattern) == 0 {
+		s.notFound.ServeHTTP(w, r)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *serveMuxRouter) Handle(route Route, handler http.Handler) error {
+	return s.handle("", route, handler)
+}
+
+func (s *serveMuxRouter) Group(prefix string) Router {
+	return serveMuxGroup{root: s, prefix: prefix}
+}
+
+func (s *serveMuxRouter) NotFoundHandler(handler http.Handler) {
+	s.notFound = handler
+}
+
+func (s *serveMuxRouter) MethodNotAllowedHandler(handler http.Handler) {
+	s.notAllowed = handler
+}
+
+func (s *serveMuxRouter) PathParam(request *http.Request, name string) string {
+	return request.PathValue(name)
+}
+
+func (s *serveMuxRouter) PathParams(request *http.Request) map[string]string {
+	return patternPathParams(request)
+}
+
+func (s *serveMuxRouter) handle(prefix string, route Route, handler http.Handler) (err error) {
+	pattern := prefix + route.Path
+
+	if len(pattern) == 0 {
+		return fmt.Errorf("invalid route: empty path")
+	}
+
+	if err := checkGorillaTemplate(pattern); err != nil {
+		return err
+	}
+
+	// Anchor patterns ending in a slash so that they match only the exact path,
+	// as a ServeMux would otherwise treat them as a prefix match.
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{$}"
+	}
+
+	if path, ok := s.routes[pattern]; ok {
+		return path.routes.add(pattern, route, handler)
+	}
+
+	// ServeMux panics on invalid or conflicting patterns.
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("invalid route %q: %v", pattern, rec)
+		}
+	}()
+
+	path := &serveMuxPath{router: s}
+	_ = path.routes.add(pattern, route, handler)
+	s.mux.Handle(pattern, path)
+	s.routes[pattern] = path
+
+	return nil
+}
+
+// serveMuxGroup is a view of a serveMuxRouter that registers routes under a
+// path prefix.
+type serveMuxGroup struct {
+	root   *serveMuxRouter
+	prefix string
+}
+
+func (s serveMuxGroup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.root.ServeHTTP(w, r)
+}
+
+func (s serveMuxGroup) Handle(route Route, handler http.Handler) error {
+	return s.root.handle(s.prefix, route, handler)
+}
+
+func (s serveMuxGroup) Group(prefix string) Router {
+	return serveMuxGroup{root: s.root, prefix: s.prefix + prefix}
+}
+
+func (s serveMuxGroup) NotFoundHandler(handler http.Handler) {
+	s.root.NotFoundHandler(handler)
+}
+
+func (s serveMuxGroup) MethodNotAllowedHandler(handler http.Handler) {
+	s.root.MethodNotAllowedHandler(handler)
+}
+
+func (s serveMuxGroup) PathParam(request *http.Request, name string) string {
+	return request.PathValue(name)
+}
+
+func (s serveMuxGroup) PathParams(request *http.Request) map[string]string {
+	return patternPathParams(request)
+}
+
+// serveMuxPath dispatches requests matching a single ServeMux pattern to the
+// route registered for that pattern that matches the request's method and
+// headers.
+type serveMuxPath struct {
+	router *serveMuxRouter
+	routes routeSet
+}
+
+func (s *serveMuxPath) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.routes.serve(w, r, s.router.notFound, s.router.notAllowed)
+}
@@ -0,0 +1,287 @@
+package swrv
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A Router matches incoming HTTP requests to the controllers built by a Server.
+//
+// Swrv includes a Router implementation backed by the standard library's
+// http.ServeMux, which is used by default, a built-in radix tree Router, and an
+// adapter for github.com/gorilla/mux in the swrvgorilla package.
+type Router interface {
+	http.Handler
+
+	// Handle registers the given handler for requests matching the given Route.
+	//
+	// An error is returned if the route is invalid or conflicts with a
+	// previously registered route.
+	Handle(route Route, handler http.Handler) error
+
+	// Group returns a Router that registers routes relative to the given path
+	// prefix under this Router.
+	//
+	// The returned Router shares the request matching of its parent, so
+	// requests should still be served through the parent Router.
+	Group(prefix string) Router
+
+	// NotFoundHandler sets the handler that will be called for requests that do
+	// not match any registered route.
+	NotFoundHandler(handler http.Handler)
+
+	// MethodNotAllowedHandler sets the handler that will be called for requests
+	// that match a registered route path, but not any of the HTTP methods
+	// registered for that path.
+	MethodNotAllowedHandler(handler http.Handler)
+
+	// PathParam returns the value of the named path parameter for a request
+	// that was routed by this Router.
+	//
+	// If the request has no such path parameter, the returned string will be
+	// empty.
+	PathParam(request *http.Request, name string) string
+
+	// PathParams returns all the path parameters for a request that was routed
+	// by this Router.
+	PathParams(request *http.Request) map[string]string
+}
+
+// Route describes the requests that a controller built by a Server should be
+// called for.
+type Route struct {
+	// Path is the URL path template for the route, relative to the Router the
+	// route is registered with.
+	//
+	// Path parameters are declared by wrapping the parameter name in braces, for
+	// example "/users/{id}".  A final path segment of the form "{name...}" is a
+	// catch-all parameter matching the remainder of the request path, for
+	// example "/files/{path...}".
+	//
+	// Every Router must either support both of these forms, or return an error
+	// from Handle for routes that use a form it does not support, rather than
+	// registering a route that would match different requests.
+	Path string
+
+	// Methods is the list of HTTP methods the route should match.
+	//
+	// If empty, the route will match any HTTP method.
+	Methods []string
+
+	// Headers is a map of header names to the values that those headers must be
+	// set to for the route to match.
+	//
+	// If a value is empty, the route will match any value set on the target
+	// header.
+	Headers map[string]string
+}
+
+// checkGorillaTemplate returns an error if the given route path uses the
+// regular expression wildcards of gorilla/mux path templates, such as
+// "/users/{id:[0-9]+}", which the built-in Routers do not support.
+//
+// Such templates are otherwise rejected with an error about the wildcard name,
+// which gives no hint that the route was written for the gorilla Router.
+func checkGorillaTemplate(template string) error {
+	for rest := template; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			return nil
+		}
+
+		rest = rest[start+1:]
+
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil
+		}
+
+		if strings.IndexByte(rest[:end], ':') >= 0 {
+			return fmt.Errorf("invalid route %q: regular expression wildcards are only supported by the gorilla/mux Router, start the server with swrvgorilla.NewRouter() to use them", template)
+		}
+
+		rest = rest[end+1:]
+	}
+}
+
+// matchesHeaders tests whether the given request contains all the headers
+// required by the route.
+func (r Route) matchesHeaders(request *http.Request) bool {
+	for header, value := range r.Headers {
+		if !headerHasValue(request.Header.Values(header), value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func headerHasValue(values []string, value string) bool {
+	if len(value) == 0 {
+		return len(values) > 0
+	}
+
+	for _, actual := range values {
+		if actual == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesMethod tests whether the route allows the given HTTP method.
+func (r Route) matchesMethod(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+
+	for _, allowed := range r.Methods {
+		if allowed == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// routeSet holds the routes registered for a single path template, and
+// dispatches requests to the route that matches the request's method and
+// headers.
+type routeSet struct {
+	routes []routeEntry
+}
+
+type routeEntry struct {
+	route   Route
+	handler http.Handler
+}
+
+// add appends the given route to the set, returning an error if the route
+// would conflict with a route already in the set.
+//
+// Two routes conflict if they require the same headers and either route would
+// match a method that the other route also matches.
+func (s *routeSet) add(path string, route Route, handler http.Handler) error {
+	for i := range s.routes {
+		existing := s.routes[i].route
+
+		if sameHeaders(existing.Headers, route.Headers) && methodsOverlap(existing.Methods, route.Methods) {
+			return fmt.Errorf("route %s %v conflicts with a previously registered route", path, route.Methods)
+		}
+	}
+
+	s.routes = append(s.routes, routeEntry{route, handler})
+	return nil
+}
+
+// serve calls the handler for the route in the set that matches the given
+// request.
+//
+// If no route's headers match the request, the notFound handler is called.  If
+// a route's headers match the request, but no route matches the request method,
+// the Allow header is set and the notAllowed handler is called.
+func (s *routeSet) serve(w http.ResponseWriter, r *http.Request, notFound, notAllowed http.Handler) {
+	var allowed []string
+	headersMatched := false
+
+	for i := range s.routes {
+		if !s.routes[i].route.matchesHeaders(r) {
+			continue
+		}
+
+		if s.routes[i].route.matchesMethod(r.Method) {
+			s.routes[i].handler.ServeHTTP(w, r)
+			return
+		}
+
+		headersMatched = true
+		allowed = append(allowed, s.routes[i].route.Methods...)
+	}
+
+	if !headersMatched {
+		notFound.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set(HeaderAllow, strings.Join(allowed, ", "))
+	notAllowed.ServeHTTP(w, r)
+}
+
+func sameHeaders(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for header, value := range a {
+		if other, ok := b[header]; !ok || other != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func methodsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+
+	for _, method := range a {
+		for _, other := range b {
+			if method == other {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// pathParamSource provides the path parameters for requests routed by a
+// Router.
+type pathParamSource interface {
+	PathParam(request *http.Request, name string) string
+	PathParams(request *http.Request) map[string]string
+}
+
+// standardPathParams resolves path parameters using the standard library's
+// http.Request.PathValue.
+type standardPathParams struct{}
+
+func (standardPathParams) PathParam(request *http.Request, name string) string {
+	return request.PathValue(name)
+}
+
+func (standardPathParams) PathParams(request *http.Request) map[string]string {
+	return patternPathParams(request)
+}
+
+// patternPathParams returns the values of all the wildcards in the pattern
+// that matched the given request, as recorded in the request's Pattern field.
+func patternPathParams(request *http.Request) map[string]string {
+	out := make(map[string]string)
+	pattern := request.Pattern
+
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			break
+		}
+
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		pattern = pattern[start+end+1:]
+
+		if name != "$" {
+			out[name] = request.PathValue(name)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,118 @@
+package swrv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvgorilla"
+)
+
+var benchRoutes = []string{
+	"/",
+	"/users",
+	"/users/{id}",
+	"/users/{id}/posts",
+	"/users/{id}/posts/{post}",
+	"/users/{id}/posts/{post}/comments",
+	"/orgs/{org}/repos/{repo}/issues/{issue}",
+	"/health",
+	"/metrics",
+}
+
+var benchPaths = []struct {
+	name string
+	path string
+}{
+	{"root", "/"},
+	{"static", "/users"},
+	{"param", "/users/42"},
+	{"params2", "/users/42/posts/7"},
+	{"params3", "/orgs/foxcapades/repos/swrv/issues/13"},
+}
+
+func BenchmarkRadixRouter(b *testing.B) {
+	benchmarkRouter(b, swrv.NewRadixRouter())
+}
+
+func BenchmarkServeMuxRouter(b *testing.B) {
+	benchmarkRouter(b, swrv.NewServeMuxRouter())
+}
+
+func BenchmarkGorillaRouter(b *testing.B) {
+	benchmarkRouter(b, swrvgorilla.NewRouter())
+}
+
+// TestRadixRouterAllocs verifies that routing a request with the radix router,
+// and reading its path parameters, does not allocate.
+func TestRadixRouterAllocs(t *testing.T) {
+	router := swrv.NewRadixRouter()
+
+	var params []string
+
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"id", "post", "org", "repo", "issue"} {
+			params = append(params[:0], router.PathParam(r, name))
+		}
+	})
+
+	for _, route := range benchRoutes {
+		if err := router.Handle(swrv.Route{Path: route, Methods: []string{http.MethodGet}}, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	params = make([]string, 0, 1)
+	writer := discardWriter{make(http.Header)}
+
+	for _, path := range benchPaths {
+		request := httptest.NewRequest(http.MethodGet, path.path, nil)
+
+		if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(writer, request) }); allocs != 0 {
+			t.Errorf("expected routing %s to not allocate, got %.1f allocations", path.path, allocs)
+		}
+	}
+}
+
+// benchmarkRouter measures the time taken by the given router to route a
+// request to each of the benchmark paths.
+//
+// Each iteration routes a fresh shallow copy of a prebuilt request, as some
+// routers record path parameters on the request they are given.  The copy
+// accounts for one allocation per operation, so a router that does not
+// allocate itself reports exactly one.
+func benchmarkRouter(b *testing.B, router swrv.Router) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	for _, route := range benchRoutes {
+		if err := router.Handle(swrv.Route{Path: route, Methods: []string{http.MethodGet}}, noop); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	writer := discardWriter{make(http.Header)}
+
+	for _, path := range benchPaths {
+		b.Run(path.name, func(b *testing.B) {
+			template := httptest.NewRequest(http.MethodGet, path.path, nil)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				request := new(http.Request)
+				*request = *template
+				router.ServeHTTP(writer, request)
+			}
+		})
+	}
+}
+
+type discardWriter struct {
+	header http.Header
+}
+
+func (d discardWriter) Header() http.Header         { return d.header }
+func (d discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d discardWriter) WriteHeader(int)             {}
@@ -13,8 +13,15 @@ type ObjectSerializer interface {
 	// Matches tests whether the given object may be serialized by the current
 	// ObjectSerializer.
 	//
-	// If this method returns true, Serialize will be called on the object and no
-	// further ObjectSerializers will be tested.
+	// Matches is called on every registered ObjectSerializer, and each one that
+	// returns true becomes a candidate for serializing the object.  Of those
+	// candidates, the one whose ContentType is most preferred by the quality
+	// values in the request's Accept header will have its Serialize method
+	// called, with ties going to the ObjectSerializer registered first.
+	//
+	// If no candidate's ContentType is acceptable to the client, the request is
+	// answered by the 406 Not Acceptable controller instead.  Error controllers,
+	// which have no 406 controller to defer to, use the first candidate.
 	Matches(object any) bool
 
 	// Serialize serializes the given object into an io.Reader instance which will
@@ -23,5 +30,8 @@ type ObjectSerializer interface {
 
 	// ContentType returns the content type of the serialized data that this
 	// ObjectSerializer returns.
+	//
+	// This value is matched against the request's Accept header when choosing
+	// between ObjectSerializers that match the same object.
 	ContentType() string
 }
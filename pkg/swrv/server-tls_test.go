@@ -0,0 +1,207 @@
+package swrv_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+// testCertificate is a certificate and private key generated for a test.
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCertificate generates a certificate from the given template, signed by
+// the given parent, or self-signed if parent is nil.
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+// writePEM writes the certificate and key to PEM files in a temporary
+// directory, returning their paths.
+func (c *testCertificate) writePEM(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestServerClientCertificateAuth(t *testing.T) {
+	ca := newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "swrv test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+
+	serverCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}, ca)
+
+	clientCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "test client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}, ca)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	certFile, keyFile := serverCert.writePEM(t)
+
+	server := swrv.NewServer("", 0).
+		WithTLS(certFile, keyFile).
+		WithClientCertificateAuth(pool).
+		WithControllers(swrv.NewController("/whoami", swrv.RequestHandlerFunc(func(request swrv.Request) swrv.Response {
+			return swrv.NewResponse().WithBody(request.PeerCertificate().Subject.CommonName)
+		})))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() { stopped <- server.Serve(ctx, listener) }()
+
+	defer func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("server did not shut down cleanly: %s", err)
+		}
+	}()
+
+	url := "https://" + listener.Addr().String() + "/whoami"
+
+	newClient := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certificates},
+			},
+		}
+	}
+
+	t.Run("client certificate accepted", func(t *testing.T) {
+		response, err := newClient(clientCert.tlsCertificate()).Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+
+		body, _ := io.ReadAll(response.Body)
+
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", response.StatusCode)
+		}
+
+		if string(body) != "test client" {
+			t.Errorf("expected peer certificate common name %q, got %q", "test client", body)
+		}
+	})
+
+	t.Run("missing client certificate rejected", func(t *testing.T) {
+		response, err := newClient().Get(url)
+		if err == nil {
+			response.Body.Close()
+			t.Fatalf("expected the TLS handshake to fail, got status %d", response.StatusCode)
+		}
+	})
+
+	t.Run("untrusted client certificate rejected", func(t *testing.T) {
+		rogueCA := newTestCertificate(t, &x509.Certificate{
+			Subject:               pkix.Name{CommonName: "rogue CA"},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}, nil)
+
+		rogue := newTestCertificate(t, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "rogue client"},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+		}, rogueCA)
+
+		// Send the certificate even though it was not issued by any of the CAs the
+		// server asks for, so that the server's verification is exercised.
+		client := newClient()
+		client.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certificate := rogue.tlsCertificate()
+			return &certificate, nil
+		}
+
+		response, err := client.Get(url)
+		if err == nil {
+			response.Body.Close()
+			t.Fatalf("expected the TLS handshake to fail, got status %d", response.StatusCode)
+		}
+	})
+}
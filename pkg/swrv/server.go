@@ -1,47 +1,49 @@
 package swrv
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/gorilla/mux"
-	"github.com/sirupsen/logrus"
 )
 
 func NewServer(host string, port uint16) Server {
 	return &server{
-		logger: logrus.WithField("log-from", "server"),
+		logger: NewSlogLogger(nil).With("log-from", "server"),
 		extras: &serverExtras{
-			readTimeout:  30 * time.Second,
-			writeTimeout: 30 * time.Second,
-			host:         host,
-			port:         port,
+			readTimeout:     30 * time.Second,
+			writeTimeout:    30 * time.Second,
+			shutdownTimeout: 30 * time.Second,
+			host:            host,
+			port:            port,
 		},
 	}
 }
 
 // A Server serves HTTP requests.
 type Server interface {
-	// WithLogger configures the server to use the given logrus logger instance
-	// for internal server logging.
+	// WithLogger configures the server to use the given Logger for internal
+	// server logging, and as the base for the request-scoped Logger available
+	// from each Request.
 	//
-	// This will overwrite any value previously set using WithLogger or
-	// WithLoggerEntry.
+	// If unset, or if nil is passed, the Server will log through the default
+	// log/slog logger.
 	//
-	// Passing nil will cause the server to panic with a nil pointer exception on
-	// startup.
-	WithLogger(logger *logrus.Logger) Server
-
-	// WithLoggerEntry configures the server to use the given logrus logger entry
-	// instance for internal server logging.
+	// Example: slog
+	//
+	//   server.WithLogger(swrv.NewSlogLogger(slog.Default()))
 	//
-	// This will overwrite any value previously set using WithLogger or
-	// WithLoggerEntry.
+	// Example: logrus
 	//
-	// Passing nil will cause the server to panic with a nil pointer exception on
-	// startup.
-	WithLoggerEntry(logger *logrus.Entry) Server
+	//   server.WithLogger(swrvlogrus.New(logrus.StandardLogger()))
+	WithLogger(logger Logger) Server
 
 	// WithReadTimeout sets the server's read timeout value to the given duration.
 	//
@@ -54,12 +56,59 @@ type Server interface {
 	// If unset, the Server will default to a 30-second timeout.
 	WithWriteTimeout(timeout time.Duration) Server
 
-	// WithControllers adds the given ControllerSpec to the Server.
+	// WithShutdownTimeout sets the maximum amount of time the server will wait
+	// for in-flight requests to complete when shutting down after the context
+	// passed to Run has been cancelled.
+	//
+	// If the timeout elapses before all active requests have completed, any
+	// remaining connections will be forcibly closed.
+	//
+	// If unset, the Server will default to a 30-second timeout.
+	WithShutdownTimeout(timeout time.Duration) Server
+
+	// WithTLS configures the server to serve HTTPS using the certificate and
+	// private key loaded from the given PEM files.
+	//
+	// If the certificate is signed by a certificate authority, the certFile
+	// should be the concatenation of the server's certificate, any
+	// intermediates, and the CA's certificate.
+	WithTLS(certFile, keyFile string) Server
+
+	// WithTLSConfig configures the server to serve HTTPS using the given TLS
+	// configuration.
+	//
+	// The given config may provide the server's certificates directly, or may be
+	// combined with WithTLS to load the certificates from files.
+	//
+	// The given config will be cloned on startup, further changes to the
+	// config after the server has been started will have no effect.
+	WithTLSConfig(config *tls.Config) Server
+
+	// WithClientCertificateAuth configures the server to require that clients
+	// present a certificate signed by one of the certificate authorities in the
+	// given pool (mutual TLS).
+	//
+	// Connections from clients that do not present a valid certificate will be
+	// rejected during the TLS handshake.  The verified client certificate is
+	// made available to filters and handlers via Request.PeerCertificate.
+	//
+	// This option has no effect unless the server has also been configured to
+	// serve TLS via WithTLS or WithTLSConfig.
+	WithClientCertificateAuth(clientCAs *x509.CertPool) Server
+
+	// WithControllers adds the given ControllerSpec instances to the Server.
 	//
-	// When the Server is started, this ControllerSpec will be built into a
+	// When the Server is started, each ControllerSpec will be built into a
 	// controller instance that will handle incoming HTTP requests that match the
 	// target path and filters.
-	WithControllers(controller ControllerSpec) Server
+	WithControllers(controllers ...ControllerSpec) Server
+
+	// WithGroups adds the given ControllerGroup instances to the Server.
+	//
+	// When the Server is started, each ControllerGroup will be built into a
+	// sub-router containing the group's controllers and nested groups, all of
+	// which will share the group's path prefix, filters, and serializers.
+	WithGroups(groups ...ControllerGroup) Server
 
 	// WithRequestFilters appends global RequestFilter instances that will be hit
 	// for requests to any controller registered with the Server instance.
@@ -87,10 +136,28 @@ type Server interface {
 	// ObjectSerializers are not applied to Response bodies of type io.Reader.
 	//
 	// ObjectSerializers will be tested in the order they are appended to the
-	// server.  The first matching serializer will be used to serialize a Response
-	// body.
+	// server.  Of the serializers that match a Response body, the one whose
+	// ContentType is most preferred by the client's Accept header will be used
+	// to serialize the body, with ties going to the first matching serializer.
+	//
+	// If none of the matching serializers produce a content type that is
+	// acceptable to the client, the request will be answered by the 406
+	// controller.
 	WithObjectSerializers(serializers ...ObjectSerializer) Server
 
+	// WithObjectDeserializers appends ObjectDeserializer instances to the Server.
+	//
+	// ObjectDeserializers are used by Request.ReadBodyInto to deserialize
+	// incoming request bodies into objects.
+	//
+	// ObjectDeserializers will be tested against the request's Content-Type in
+	// the order they are appended to the server.  The first matching
+	// deserializer will be used to deserialize the request body.  If no
+	// deserializer matches, JSON request bodies will be deserialized by the
+	// default JSON deserializer, and any other request will be answered with a
+	// 415 Unsupported Media Type error.
+	WithObjectDeserializers(deserializers ...ObjectDeserializer) Server
+
 	// With404Controller configures the Server's 404 Not Found controller, that
 	// is, the controller that will be called when a client makes a request to an
 	// endpoint that is not registered to the Server.
@@ -108,11 +175,240 @@ type Server interface {
 	// RequestFilter and ResponseFilter instances like a normal controller.
 	With405Controller(useGlobalFilters bool, controller ErrorControllerSpec) Server
 
+	// With406Controller configures the Server's 406 Not Acceptable controller,
+	// that is, the controller that will be called when a controller returns a
+	// Response body that cannot be serialized into any of the content types
+	// listed in the client's Accept header.
+	//
+	// Optionally requests to this controller may choose to use the global
+	// RequestFilter and ResponseFilter instances like a normal controller.
+	//
+	// If unset, a default controller returning a plain-text 406 error, and using
+	// the global filters, will be used.
+	With406Controller(useGlobalFilters bool, controller ErrorControllerSpec) Server
+
+	// With500Controller configures the Server's 500 Internal Server Error
+	// controller, that is, the controller that will be called when a panic
+	// occurs while processing a request in any RequestFilter, RequestHandler,
+	// ResponseFilter, or ObjectSerializer.
+	//
+	// Recovered panics are logged along with their stack trace before the 500
+	// controller is called.  If the response had already been partially written
+	// when the panic occurred, the connection will be aborted instead.
+	//
+	// The 500 controller's own filters are applied to the request, but the
+	// global RequestFilter instances are not run again, as the request has
+	// already passed through them, or panicked in one of them.  Optionally the
+	// response may be passed through the ResponseFilter instances of the
+	// controller that panicked, including the global ResponseFilters.
+	//
+	// If unset, a default controller returning a plain-text 500 error, and using
+	// the global filters, will be used.
+	With500Controller(useGlobalFilters bool, controller ErrorControllerSpec) Server
+
+	// With413Controller configures the Server's 413 Content Too Large
+	// controller, that is, the controller that will be called when a request
+	// body exceeds the maximum body size configured with WithMaxBodySize or
+	// ControllerSpec.WithMaxBodySize.
+	//
+	// Optionally requests to this controller may choose to use the global
+	// RequestFilter and ResponseFilter instances like a normal controller.
+	//
+	// If unset, a default controller returning a plain-text 413 error, and using
+	// the global filters, will be used.
+	With413Controller(useGlobalFilters bool, controller ErrorControllerSpec) Server
+
+	// WithMaxBodySize sets the maximum size, in bytes, of request bodies that
+	// will be accepted by the Server's controllers.
+	//
+	// Requests declaring a larger Content-Length are rejected before any filters
+	// are called.  Requests with bodies that are found to exceed the maximum
+	// size while being read are answered with a 413 Content Too Large error
+	// regardless of the Response returned by the RequestHandler, and the read
+	// fails with ErrRequestBodyTooLarge.
+	//
+	// Request bodies sent with a "gzip" or "deflate" Content-Encoding are
+	// decompressed transparently, with the maximum size applying to both the
+	// compressed and decompressed body.  When no maximum size is set,
+	// decompressed bodies are still limited to DefaultMaxDecodedBodySize bytes.
+	// Requests using any other content coding are answered with a 415
+	// Unsupported Media Type error.
+	//
+	// Example:
+	//
+	//   server.WithMaxBodySize(1 << 20).
+	//     WithControllers(swrv.NewController("/uploads", uploadHandler).
+	//       WithMaxBodySize(64 << 20))
+	//
+	// If unset, or if 0 is passed, request bodies are unlimited.
+	WithMaxBodySize(bytes int64) Server
+
+	// WithErrorMapping registers an ErrorMapper that will be used to convert
+	// errors returned by RequestHandlerE and RequestFilterE instances into
+	// responses when the error matches the given target error according to
+	// errors.Is.
+	//
+	// Error mappings are tested in the order they are registered.  Errors that
+	// do not match any registered mapping are passed to the fallback
+	// ErrorMapper.
+	WithErrorMapping(target error, mapper ErrorMapper) Server
+
+	// WithErrorMapper sets the fallback ErrorMapper that will be used to convert
+	// errors returned by RequestHandlerE and RequestFilterE instances that do not
+	// match any mapping registered with WithErrorMapping.
+	//
+	// If unset, or if the given mapper returns nil, the DefaultErrorMapper will
+	// be used.
+	WithErrorMapper(mapper ErrorMapper) Server
+
+	// WithProblemDetails configures whether errors generated by the Server
+	// itself should be returned as RFC 9457 "application/problem+json"
+	// documents rather than plain text.
+	//
+	// This applies to the default 404, 405, 406, 413, and 500 responses,
+	// responses for nil handler or filter results, serialization failures,
+	// unsupported request content encodings, and errors converted by the
+	// DefaultErrorMapper.  Custom error controllers and ErrorMappers are
+	// unaffected.
+	//
+	// If unset, framework errors are returned as plain text.
+	WithProblemDetails(enabled bool) Server
+
+	// WithCORS configures the server to apply the given Cross-Origin Resource
+	// Sharing policy.
+	//
+	// The server will answer CORS preflight requests for every registered
+	// controller path, advertising the methods registered for that path, and
+	// will add CORS headers to the responses for requests from allowed origins.
+	// Preflight requests are answered before any filters are applied.
+	//
+	// Example:
+	//
+	//   server.WithCORS(swrv.NewCORS().
+	//     WithAllowedOrigins("https://*.example.com").
+	//     WithCredentials(true).
+	//     WithMaxAge(time.Hour))
+	//
+	// If unset, or if nil is passed, no CORS headers will be sent.
+	WithCORS(cors CORS) Server
+
+	// WithCompression configures the server to compress response bodies using
+	// the given Compression policy.
+	//
+	// Example:
+	//
+	//   server.WithCompression(swrv.NewCompression().
+	//     WithThreshold(512).
+	//     WithContentTypes("text/*", swrv.ContentTypeApplicationJSON))
+	//
+	// If unset, or if nil is passed, responses will not be compressed.
+	WithCompression(compression Compression) Server
+
+	// WithAccessLog configures the server to record an AccessLogEntry for every
+	// request it handles, including requests that do not match any controller,
+	// to the given AccessLog.
+	//
+	// Example: Combined Log Format to stdout
+	//
+	//   server.WithAccessLog(swrv.NewAccessLogWriter(swrv.AccessLogCombined, os.Stdout))
+	//
+	// Example: Server Logger
+	//
+	//   server.WithAccessLog(swrv.NewAccessLogLogger(nil))
+	//
+	// If unset, or if nil is passed, no access log will be recorded.
+	WithAccessLog(log AccessLog) Server
+
+	// Run starts the server, binding to the configured port and address,
+	// optionally using a given router, and blocks until the server stops.
+	//
+	// If the router parameter is nil, a new router backed by http.ServeMux will
+	// be initialized for the server.  See NewServeMuxRouter.
+	//
+	// When the given context is cancelled, the server will stop accepting new
+	// connections and wait for in-flight requests to complete, up to the
+	// configured shutdown timeout, before returning.  A clean shutdown returns
+	// nil; otherwise the error that caused the server to stop is returned.
+	//
+	// Once a server has started, no new filters, controllers, or serializers may
+	// be registered.
+	//
+	// A server may only be started once.  Attempting to start a server a second
+	// time will return ErrServerStarted.
+	//
+	// If any of the server's controllers cannot be registered with the router,
+	// for example because its path uses syntax the router does not support, the
+	// registration error is returned without the server being started.
+	//
+	// Example:
+	//
+	//   ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	//   defer stop()
+	//
+	//   server := swrv.NewServer(address, port)
+	//   ...
+	//   if err := server.Run(ctx, nil); err != nil {
+	//     log.Fatal(err)
+	//   }
+	Run(ctx context.Context, router Router) error
+
+	// Handler builds the server's controllers into an http.Handler without
+	// binding to any port.
+	//
+	// This may be used to exercise the server's controllers in tests, or to
+	// mount the server within another HTTP server.
+	//
+	// Once the handler has been built, no new filters, controllers, or
+	// serializers may be registered.  Subsequent calls to Handler, Run, or Start
+	// will use the same handler instance.
+	//
+	// Handler panics if any of the server's controllers cannot be registered
+	// with the router.
+	Handler() http.Handler
+
+	// Serve serves the server on the given listener, rather than binding to the
+	// configured host and port, and blocks until the server stops.
+	//
+	// This may be used to listen on a Unix domain socket, on a listener passed
+	// in via systemd socket activation, or on an ephemeral TCP port whose
+	// address may be read back from the listener.
+	//
+	// Serve behaves the same as Run with a nil router: when the given context is
+	// cancelled, the server will stop accepting new connections and wait for
+	// in-flight requests to complete, up to the configured shutdown timeout,
+	// before returning.  The listener will be closed when Serve returns.
+	//
+	// A server may only be started once.  Attempting to start a server a second
+	// time will return ErrServerStarted.
+	//
+	// Example: Unix Domain Socket
+	//
+	//   listener, err := net.Listen("unix", "/run/app.sock")
+	//   ...
+	//   err = server.Serve(ctx, listener)
+	//
+	// Example: Ephemeral Port
+	//
+	//   listener, err := net.Listen("tcp", "127.0.0.1:0")
+	//   ...
+	//   port := listener.Addr().(*net.TCPAddr).Port
+	//   err = server.Serve(ctx, listener)
+	Serve(ctx context.Context, listener net.Listener) error
+
+	// Addr returns the network address the server is listening on.
+	//
+	// If the server has not yet started listening, the returned value will be
+	// nil.
+	Addr() net.Addr
+
 	// Start starts the server, binding to the configured port and address,
 	// optionally using a given router.
 	//
-	// If the router parameter is nil, a new router will be initialized for the
-	// server.
+	// Start is a convenience wrapper around Run that never stops the server and
+	// exits the process if the server fails.
+	//
+	// If the router parameter is nil, a new router backed by http.ServeMux will
+	// be initialized for the server.  See NewServeMuxRouter.
 	//
 	// Once a server has started, no new filters, controllers, or serializers may
 	// be registered.
@@ -126,53 +422,110 @@ type Server interface {
 	//
 	// Example: With Router
 	//
-	//   router := mux.NewRouter()
+	//   router := swrvgorilla.NewRouter()
 	//   server := xhttp.NewServer(address, port)
 	//   ...
 	//   server.Start(router)
-	Start(router *mux.Router)
+	Start(router Router)
 }
 
+// ErrServerStarted is returned when attempting to start a Server instance that
+// has already been started.
+var ErrServerStarted = errors.New("server has already been started")
+
 type serverExtras struct {
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	host         string
-	port         uint16
-	useFilt404   bool
-	useFilt405   bool
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	shutdownTimeout time.Duration
+	tlsCertFile     string
+	tlsKeyFile      string
+	tlsConfig       *tls.Config
+	clientCAs       *x509.CertPool
+	host            string
+	port            uint16
+	useFilt404      bool
+	useFilt405      bool
+	useFilt406      bool
+	useFilt500      bool
+	useFilt413      bool
+	maxBodySize     int64
+	problems        bool
+	accessLog       AccessLog
+	cors            CORS
+	compression     Compression
 }
 
 type server struct {
-	logger      *logrus.Entry
-	started     bool
-	inFilters   []RequestFilter
-	outFilters  []ResponseFilter
-	controllers []ControllerSpec
-	serializers []ObjectSerializer
-	handler404  ErrorControllerSpec
-	handler405  ErrorControllerSpec
-	extras      *serverExtras
+	logger        Logger
+	started       bool
+	handler       http.Handler
+	addr          atomic.Value
+	inFilters     []RequestFilter
+	outFilters    []ResponseFilter
+	controllers   []ControllerSpec
+	groups        []ControllerGroup
+	serializers   []ObjectSerializer
+	deserializers []ObjectDeserializer
+	handler404    ErrorControllerSpec
+	handler405    ErrorControllerSpec
+	handler406    ErrorControllerSpec
+	handler500    ErrorControllerSpec
+	handler413    ErrorControllerSpec
+	errMappings   []errorMapping
+	errMapper     ErrorMapper
+	extras        *serverExtras
+
+	// cors holds the resolved CORS policy while the server is being built.
+	cors *corsPolicy
+
+	// paths and pathOrder record the controllers registered for each route path
+	// template while the server is being built.
+	paths     map[string]*routePath
+	pathOrder []*routePath
+
+	// mutex guards running and the building of the handler, as Run, Serve, and
+	// Handler may be called concurrently.
+	mutex   sync.Mutex
+	running bool
+
+	// buildErr holds the error that prevented the handler from being built, if
+	// any.
+	buildErr error
 }
 
 // Logging /////////////////////////////////////////////////////////////////////
 
-func (s *server) WithLogger(logger *logrus.Logger) Server {
-	s.logger = logger.WithField("log-from", "server")
+func (s *server) WithLogger(logger Logger) Server {
+	if logger == nil {
+		logger = NewSlogLogger(nil)
+	}
+
+	s.logger = logger.With("log-from", "server")
 	return s
 }
 
-func (s *server) WithLoggerEntry(logger *logrus.Entry) Server {
-	s.logger = logger.WithField("log-from", "server")
-	return s
+// fatal logs the given message and exits the process.  It is used when the
+// server has been misconfigured in a way that it cannot recover from.
+func (s *server) fatal(msg string, args ...any) {
+	s.logger.Error(msg, args...)
+	os.Exit(1)
 }
 
 // Controller //////////////////////////////////////////////////////////////////
 
-func (s *server) WithControllers(controller ControllerSpec) Server {
+func (s *server) WithControllers(controllers ...ControllerSpec) Server {
 	if s.started {
-		s.logger.Fatalln("cannot add controllers to a server after it has started")
+		s.fatal("cannot add controllers to a server after it has started")
 	}
-	s.controllers = append(s.controllers, controller)
+	s.controllers = append(s.controllers, controllers...)
+	return s
+}
+
+func (s *server) WithGroups(groups ...ControllerGroup) Server {
+	if s.started {
+		s.fatal("cannot add controller groups to a server after it has started")
+	}
+	s.groups = append(s.groups, groups...)
 	return s
 }
 
@@ -180,7 +533,7 @@ func (s *server) WithControllers(controller ControllerSpec) Server {
 
 func (s *server) WithRequestFilters(filters ...RequestFilter) Server {
 	if s.started {
-		s.logger.Fatalln("cannot add request filters to a server after it has started")
+		s.fatal("cannot add request filters to a server after it has started")
 	}
 	s.inFilters = append(s.inFilters, filters...)
 	return s
@@ -188,7 +541,7 @@ func (s *server) WithRequestFilters(filters ...RequestFilter) Server {
 
 func (s *server) WithResponseFilters(filters ...ResponseFilter) Server {
 	if s.started {
-		s.logger.Fatalln("cannot add response filters to a server after it has started")
+		s.fatal("cannot add response filters to a server after it has started")
 	}
 	s.outFilters = append(s.outFilters, filters...)
 	return s
@@ -198,12 +551,20 @@ func (s *server) WithResponseFilters(filters ...ResponseFilter) Server {
 
 func (s *server) WithObjectSerializers(serializers ...ObjectSerializer) Server {
 	if s.started {
-		s.logger.Fatalln("cannot set an object serializer on a server after it has started")
+		s.fatal("cannot set an object serializer on a server after it has started")
 	}
 	s.serializers = append(s.serializers, serializers...)
 	return s
 }
 
+func (s *server) WithObjectDeserializers(deserializers ...ObjectDeserializer) Server {
+	if s.started {
+		s.fatal("cannot set an object deserializer on a server after it has started")
+	}
+	s.deserializers = append(s.deserializers, deserializers...)
+	return s
+}
+
 // Timeouts ////////////////////////////////////////////////////////////////////
 
 func (s *server) WithReadTimeout(timeout time.Duration) Server {
@@ -216,6 +577,29 @@ func (s *server) WithWriteTimeout(timeout time.Duration) Server {
 	return s
 }
 
+func (s *server) WithShutdownTimeout(timeout time.Duration) Server {
+	s.extras.shutdownTimeout = timeout
+	return s
+}
+
+// TLS /////////////////////////////////////////////////////////////////////////
+
+func (s *server) WithTLS(certFile, keyFile string) Server {
+	s.extras.tlsCertFile = certFile
+	s.extras.tlsKeyFile = keyFile
+	return s
+}
+
+func (s *server) WithTLSConfig(config *tls.Config) Server {
+	s.extras.tlsConfig = config
+	return s
+}
+
+func (s *server) WithClientCertificateAuth(clientCAs *x509.CertPool) Server {
+	s.extras.clientCAs = clientCAs
+	return s
+}
+
 // Error Handling //////////////////////////////////////////////////////////////
 
 func (s *server) With404Controller(
@@ -236,53 +620,440 @@ func (s *server) With405Controller(
 	return s
 }
 
-// Run /////////////////////////////////////////////////////////////////////////
+func (s *server) With406Controller(
+	useGlobalFilters bool,
+	controller ErrorControllerSpec,
+) Server {
+	s.handler406 = controller
+	s.extras.useFilt406 = useGlobalFilters
+	return s
+}
 
-func (s *server) Start(router *mux.Router) {
+func (s *server) With500Controller(
+	useGlobalFilters bool,
+	controller ErrorControllerSpec,
+) Server {
+	s.handler500 = controller
+	s.extras.useFilt500 = useGlobalFilters
+	return s
+}
+
+func (s *server) With413Controller(
+	useGlobalFilters bool,
+	controller ErrorControllerSpec,
+) Server {
+	s.handler413 = controller
+	s.extras.useFilt413 = useGlobalFilters
+	return s
+}
+
+func (s *server) WithMaxBodySize(bytes int64) Server {
+	s.extras.maxBodySize = bytes
+	return s
+}
+
+func (s *server) WithErrorMapping(target error, mapper ErrorMapper) Server {
 	if s.started {
-		s.logger.Warnln("attempted to start a server instance more than once, ignoring")
+		s.fatal("cannot add error mappings to a server after it has started")
+	}
+	s.errMappings = append(s.errMappings, errorMapping{target, mapper})
+	return s
+}
+
+func (s *server) WithErrorMapper(mapper ErrorMapper) Server {
+	s.errMapper = mapper
+	return s
+}
+
+func (s *server) WithProblemDetails(enabled bool) Server {
+	s.extras.problems = enabled
+	return s
+}
+
+func (s *server) WithCORS(cors CORS) Server {
+	s.extras.cors = cors
+	return s
+}
+
+func (s *server) WithCompression(compression Compression) Server {
+	s.extras.compression = compression
+	return s
+}
+
+func (s *server) WithAccessLog(log AccessLog) Server {
+	s.extras.accessLog = log
+	return s
+}
+
+// Run /////////////////////////////////////////////////////////////////////////
+
+func (s *server) Start(router Router) {
+	if err := s.Run(context.Background(), router); err != nil {
+		if errors.Is(err, ErrServerStarted) {
+			s.logger.Warn("attempted to start a server instance more than once, ignoring")
+			return
+		}
+
+		s.fatal("server failed", "error", err)
 	}
+}
+
+func (s *server) Handler() http.Handler {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.handler == nil {
+		if err := s.buildHandler(nil); err != nil {
+			panic(err)
+		}
+	}
+
+	return s.handler
+}
+
+func (s *server) Run(ctx context.Context, router Router) error {
+	if s.isRunning() {
+		return ErrServerStarted
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.extras.host, s.extras.port))
+	if err != nil {
+		return err
+	}
+
+	if err = s.prepare(router); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	return s.serve(ctx, listener)
+}
+
+func (s *server) Serve(ctx context.Context, listener net.Listener) error {
+	if err := s.prepare(nil); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	return s.serve(ctx, listener)
+}
+
+func (s *server) Addr() net.Addr {
+	if addr, ok := s.addr.Load().(net.Addr); ok {
+		return addr
+	}
+
+	return nil
+}
+
+// Internals ///////////////////////////////////////////////////////////////////
+
+// isRunning tests whether the server has been started.
+func (s *server) isRunning() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.running
+}
+
+// prepare marks the server as running and builds the server's handler if it
+// has not already been built.
+//
+// The server is only marked as running once it has a listener to serve on, so
+// that a server that failed to bind to its address may be started again.
+func (s *server) prepare(router Router) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return ErrServerStarted
+	}
+
+	if s.handler == nil {
+		if err := s.buildHandler(router); err != nil {
+			return err
+		}
+	} else if router != nil {
+		s.logger.Warn("server handler has already been built, ignoring given router")
+	}
+
+	s.running = true
+
+	return nil
+}
+
+// serve serves the server's handler on the given listener until the given
+// context is cancelled, at which point the server is gracefully shut down.
+func (s *server) serve(ctx context.Context, listener net.Listener) error {
+	serve := &http.Server{
+		Handler:      s.handler,
+		ReadTimeout:  s.extras.readTimeout,
+		WriteTimeout: s.extras.writeTimeout,
+		TLSConfig:    s.buildTLSConfig(),
+	}
+
+	shutdownTimeout := s.extras.shutdownTimeout
+	certFile, keyFile := s.extras.tlsCertFile, s.extras.tlsKeyFile
+
+	s.addr.Store(listener.Addr())
+
+	errs := make(chan error, 1)
+
+	go func() {
+		if serve.TLSConfig != nil {
+			s.logger.Info("starting TLS server", "address", listener.Addr())
+			errs <- serve.ServeTLS(listener, certFile, keyFile)
+		} else {
+			s.logger.Info("starting server", "address", listener.Addr())
+			errs <- serve.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("shutting down server, waiting for in-flight requests to complete")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := serve.Shutdown(shutdownCtx); err != nil {
+		s.logger.Warn("server did not shut down cleanly, closing remaining connections", "error", err)
+		_ = serve.Close()
+		return err
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	s.logger.Info("server shut down cleanly")
+
+	return nil
+}
+
+// buildHandler builds the server's controllers into the given router, or a new
+// router if the given router is nil, and sets the result as the server's
+// handler.
+//
+// Once the handler has been built, the server's configuration is cleared and
+// no further filters, controllers, or serializers may be registered.
+//
+// If any controller could not be registered with the router, the error is
+// returned, and returned again by any later attempt to build the handler.
+func (s *server) buildHandler(router Router) error {
+	if s.buildErr != nil {
+		return s.buildErr
+	}
+
+	s.started = true
 
 	if router == nil {
-		s.logger.Debugln("no router passed to Start, using default router")
-		router = mux.NewRouter()
+		s.logger.Debug("no router provided, using default router")
+		router = NewServeMuxRouter()
+	}
+
+	errHandlers := s.baseErrorHandlers()
+	problems := s.extras.problems
+
+	if s.extras.cors != nil {
+		s.cors = s.extras.cors.policy()
+	}
+
+	if s.handler406 != nil {
+		s.logger.Debug("registering custom 406 handler")
+		errHandlers.notAcceptable = s.buildErrorController(s.extras.useFilt406, s.handler406, router, 406)
+	} else {
+		errHandlers.notAcceptable = s.buildErrorController(true, defaultErrorController(problems, 406, "none of the requested content types are available"), router, 406)
 	}
 
-	s.build(router)
+	if s.handler500 != nil {
+		s.logger.Debug("registering custom 500 handler")
+		errHandlers.internalError = buildPanicController(s.extras.useFilt500, s.handler500)
+	} else {
+		errHandlers.internalError = buildPanicController(true, defaultErrorController(problems, 500, http.StatusText(500)))
+	}
+
+	if s.handler413 != nil {
+		s.logger.Debug("registering custom 413 handler")
+		errHandlers.requestTooLarge = s.buildErrorController(s.extras.useFilt413, s.handler413, router, 413)
+	} else {
+		errHandlers.requestTooLarge = s.buildErrorController(true, defaultErrorController(problems, 413, "request body too large"), router, 413)
+	}
+
+	if err := s.build(router, errHandlers); err != nil {
+		s.buildErr = err
+		return err
+	}
 
 	if s.handler404 != nil {
-		s.logger.Debugln("registering custom 404 handler")
-		s.buildErrorController(s.extras.useFilt404, s.handler404, &router.NotFoundHandler, 404)
+		s.logger.Debug("registering custom 404 handler")
+		router.NotFoundHandler(s.buildErrorController(s.extras.useFilt404, s.handler404, router, 404))
+	} else if problems {
+		router.NotFoundHandler(s.buildErrorController(false, defaultErrorController(problems, 404, http.StatusText(404)), router, 404))
 	}
 
 	if s.handler405 != nil {
-		s.logger.Debugln("registering custom 405 handler")
-		s.buildErrorController(s.extras.useFilt405, s.handler405, &router.MethodNotAllowedHandler, 405)
+		s.logger.Debug("registering custom 405 handler")
+		router.MethodNotAllowedHandler(s.buildErrorController(s.extras.useFilt405, s.handler405, router, 405))
+	} else if problems {
+		router.MethodNotAllowedHandler(s.buildErrorController(false, defaultErrorController(problems, 405, http.StatusText(405)), router, 405))
 	}
 
-	serve := http.Server{
-		Addr:         fmt.Sprintf("%s:%d", s.extras.host, s.extras.port),
-		Handler:      router,
-		ReadTimeout:  s.extras.readTimeout,
-		WriteTimeout: s.extras.writeTimeout,
+	s.handler = router
+
+	if s.extras.compression != nil {
+		s.handler = compressionHandler{handler: s.handler, policy: s.extras.compression.settings()}
+	}
+
+	if s.cors != nil {
+		s.handler = corsHandler{handler: s.handler, policy: s.cors}
+	}
+
+	if log := s.extras.accessLog; log != nil {
+		if l, ok := log.(loggerAccessLog); ok && l.logger == nil {
+			log = loggerAccessLog{s.logger}
+		}
+
+		s.handler = accessLogHandler{handler: s.handler, log: log}
 	}
 
 	s.clear()
 
-	s.logger.Infof("starting server at %s\n", serve.Addr)
-	s.logger.Fatalln(serve.ListenAndServe())
+	return nil
 }
 
-// Internals ///////////////////////////////////////////////////////////////////
+// buildScope holds the configuration inherited by controllers from the Server
+// and any ControllerGroups they are nested within.
+type buildScope struct {
+	prefix      string
+	inFilters   []RequestFilter
+	outFilters  []ResponseFilter
+	serializers []ObjectSerializer
+	errHandlers errorHandlers
+}
+
+// nest returns a new buildScope for the given group, nested within the current
+// scope.
+func (b buildScope) nest(group ControllerGroup) buildScope {
+	return buildScope{
+		prefix:      b.prefix + group.GetPrefix(),
+		inFilters:   joinSlices(b.inFilters, group.GetRequestFilters()),
+		outFilters:  joinSlices(group.GetResponseFilters(), b.outFilters),
+		serializers: joinSlices(group.GetObjectSerializers(), b.serializers),
+		errHandlers: b.errHandlers,
+	}
+}
+
+// joinSlices returns a new slice containing the values of all the given
+// slices.
+//
+// Unlike append, the returned slice never shares a backing array with any of
+// the inputs.
+func joinSlices[T any](slices ...[]T) []T {
+	size := 0
+	for _, slice := range slices {
+		size += len(slice)
+	}
+
+	out := make([]T, 0, size)
+	for _, slice := range slices {
+		out = append(out, slice...)
+	}
+
+	return out
+}
 
-func (s *server) build(router *mux.Router) {
-	if len(s.controllers) == 0 {
-		s.logger.Fatalln("attempted to start a server with no controllers registered")
+func (s *server) build(router Router, errHandlers errorHandlers) error {
+	if len(s.controllers) == 0 && len(s.groups) == 0 {
+		return errors.New("attempted to start a server with no controllers registered")
 	}
 
-	s.logger.Debugln("building controllers")
+	scope := buildScope{
+		inFilters:   s.inFilters,
+		outFilters:  s.outFilters,
+		serializers: s.serializers,
+		errHandlers: errHandlers,
+	}
+
+	s.logger.Debug("building controllers")
 	for _, controller := range s.controllers {
-		s.buildController(controller, router)
+		if err := s.buildController(controller, router, scope); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Debug("building controller groups")
+	for _, group := range s.groups {
+		if err := s.buildGroup(group, router, scope); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Debug("building automatic HEAD and OPTIONS handlers")
+	return s.buildAutoMethods()
+}
+
+func (s *server) buildGroup(group ControllerGroup, router Router, parent buildScope) error {
+	scope := parent.nest(group)
+
+	s.logger.Debug("building controller group", "prefix", scope.prefix)
+
+	subRouter := router.Group(group.GetPrefix())
+
+	for _, controller := range group.GetControllers() {
+		if err := s.buildController(controller, subRouter, scope); err != nil {
+			return err
+		}
+	}
+
+	for _, nested := range group.GetGroups() {
+		if err := s.buildGroup(nested, subRouter, scope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildTLSConfig returns the TLS configuration the server should be started
+// with, or nil if the server has not been configured to serve TLS.
+func (s *server) buildTLSConfig() *tls.Config {
+	var config *tls.Config
+
+	if s.extras.tlsConfig != nil {
+		config = s.extras.tlsConfig.Clone()
+	} else if len(s.extras.tlsCertFile) > 0 || len(s.extras.tlsKeyFile) > 0 {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		if s.extras.clientCAs != nil {
+			s.logger.Warn("client certificate auth was configured without TLS, ignoring")
+		}
+		return nil
+	}
+
+	if s.extras.clientCAs != nil {
+		s.logger.Debug("enabling client certificate verification")
+		config.ClientCAs = s.extras.clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config
+}
+
+// baseErrorHandlers returns a new errorHandlers instance with the server's
+// error mapping configuration, but no error controllers.
+func (s *server) baseErrorHandlers() errorHandlers {
+	return errorHandlers{
+		mapper: compositeErrorMapper{
+			mappings: s.errMappings,
+			fallback: s.errMapper,
+			problems: s.extras.problems,
+		},
+		problems: s.extras.problems,
 	}
 }
 
@@ -290,74 +1061,209 @@ func (s *server) clear() {
 	s.inFilters = nil
 	s.outFilters = nil
 	s.controllers = nil
+	s.groups = nil
 	s.serializers = nil
+	s.deserializers = nil
+	s.handler500 = nil
+	s.handler413 = nil
+	s.errMappings = nil
+	s.errMapper = nil
+	s.handler406 = nil
 	s.handler405 = nil
 	s.handler404 = nil
-	s.extras = nil
+	s.paths = nil
+	s.pathOrder = nil
 }
 
 func (s *server) buildErrorController(
 	appendGlobals bool,
 	spec ErrorControllerSpec,
-	slot *http.Handler,
+	router Router,
 	code int,
-) {
+) http.Handler {
 	var inFilters []RequestFilter
 	var outFilters []ResponseFilter
 
 	if appendGlobals {
-		inFilters = append(s.inFilters, spec.GetRequestFilters()...)
-		outFilters = append(spec.GetResponseFilters(), s.outFilters...)
+		inFilters = joinSlices(s.inFilters, spec.GetRequestFilters())
+		outFilters = joinSlices(spec.GetResponseFilters(), s.outFilters)
 	} else {
 		inFilters = spec.GetRequestFilters()
 		outFilters = spec.GetResponseFilters()
 	}
 
-	*slot = newController(
+	return newController(
+		"",
 		inFilters,
 		outFilters,
 		spec.GetHandler(),
 		s.serializers,
-		s.logger.WithField("controller", code),
+		s.deserializers,
+		router,
+		s.baseErrorHandlers(),
+		s.logger.With("controller", code),
+		0,
 	)
 }
 
-func (s *server) buildController(spec ControllerSpec, router *mux.Router) {
-	inFilters := append(s.inFilters, spec.GetRequestFilters()...)
-	outFilters := append(spec.GetResponseFilters(), s.outFilters...)
+// buildPanicController builds the panicController for the given 500
+// ErrorControllerSpec.
+//
+// The global filters are not added to the controller's filters, as the 500
+// response is sent through the filters of the controller that panicked.
+func buildPanicController(useGlobalFilters bool, spec ErrorControllerSpec) *panicController {
+	return &panicController{
+		handler:    spec.GetHandler(),
+		inFilters:  spec.GetRequestFilters(),
+		outFilters: spec.GetResponseFilters(),
+		useFilters: useGlobalFilters,
+	}
+}
+
+func (s *server) buildController(spec ControllerSpec, router Router, scope buildScope) error {
+	inFilters := joinSlices(scope.inFilters, spec.GetRequestFilters())
+	outFilters := joinSlices(spec.GetResponseFilters(), scope.outFilters)
 
 	// Ensure we have a valid path
 	if len(spec.GetPath()) == 0 {
-		s.logger.Fatalln("controller has an empty path")
+		return errors.New("controller has an empty path")
 	}
 
-	s.logger.Tracef("building controller %s\n", spec.GetPath())
-
-	route := router.Path(spec.GetPath())
+	s.logger.Debug("building controller", "controller", scope.prefix+spec.GetPath())
 
-	// If the controller should only fire for specific HTTP methods
-	if len(spec.GetMethods()) > 0 {
-		route.Methods(spec.GetMethods()...)
+	route := Route{
+		Path:    spec.GetPath(),
+		Methods: spec.GetMethods(),
+		Headers: spec.GetRequiredHeaders(),
 	}
 
-	// If the controller requires specific headers to be set.
-	if len(spec.GetRequiredHeaders()) > 0 {
-		pairs := make([]string, 0, len(spec.GetRequiredHeaders())*2)
-
-		for head, match := range spec.GetRequiredHeaders() {
-			pairs = append(pairs, head, match)
-		}
-
-		route.Headers(pairs...)
+	path, err := s.routePath(spec, router, scope)
+	if err != nil {
+		return err
 	}
 
 	// Build the controller.
-	route.Handler(newController(
+	controller := newController(
+		scope.prefix+spec.GetPath(),
 		inFilters,
 		outFilters,
 		spec.GetHandler(),
-		s.serializers,
-		s.logger.WithField("controller", spec.GetPath()),
-	))
+		scope.serializers,
+		s.deserializers,
+		router,
+		scope.errHandlers,
+		s.logger.With("controller", scope.prefix+spec.GetPath()),
+		s.maxBodySize(spec),
+	)
+
+	if err := router.Handle(route, controller); err != nil {
+		return fmt.Errorf("failed to register controller %q: %w", scope.prefix+spec.GetPath(), err)
+	}
+
+	path.add(route, controller)
+
+	return nil
+}
+
+// maxBodySize returns the maximum request body size for the given controller,
+// or 0 if request bodies are unlimited.
+func (s *server) maxBodySize(spec ControllerSpec) int64 {
+	switch size := spec.GetMaxBodySize(); {
+	case size > 0:
+		return size
+	case size < 0:
+		return 0
+	default:
+		return s.extras.maxBodySize
+	}
+}
+
+// routePath returns the routePath for the given controller's path template,
+// creating it if this is the first controller registered for the path.
+//
+// If the server has a CORS policy, a handler for preflight requests to the path
+// is registered when the routePath is created, before the first controller for
+// the path, so that it takes precedence over controllers that accept OPTIONS
+// requests.
+func (s *server) routePath(spec ControllerSpec, router Router, scope buildScope) (*routePath, error) {
+	template := scope.prefix + spec.GetPath()
+
+	if s.paths == nil {
+		s.paths = make(map[string]*routePath)
+	}
+
+	if path, ok := s.paths[template]; ok {
+		return path, nil
+	}
+
+	path := &routePath{router: router, path: spec.GetPath(), template: template}
+	s.paths[template] = path
+	s.pathOrder = append(s.pathOrder, path)
+
+	if s.cors != nil {
+		route := Route{
+			Path:    spec.GetPath(),
+			Methods: []string{http.MethodOptions},
+			Headers: map[string]string{HeaderAccessControlRequestMethod: ""},
+		}
+
+		if err := router.Handle(route, &corsPreflight{policy: s.cors, path: path}); err != nil {
+			return nil, fmt.Errorf("failed to register CORS preflight handler for %q: %w", template, err)
+		}
+	}
+
+	return path, nil
+}
+
+// buildAutoMethods registers handlers for HEAD and OPTIONS requests to every
+// registered path that does not already have a controller for those methods.
+//
+// HEAD requests are handled by the path's GET controllers with the response
+// body omitted, and OPTIONS requests are answered with the path's allowed
+// methods.
+func (s *server) buildAutoMethods() error {
+	for _, path := range s.pathOrder {
+		if path.any {
+			continue
+		}
+
+		if !path.allowsMethod(http.MethodHead) {
+			for _, entry := range path.routes {
+				if !containsFold(entry.route.Methods, http.MethodGet) {
+					continue
+				}
+
+				err := s.registerAutoMethod(path, Route{
+					Path:    path.path,
+					Methods: []string{http.MethodHead},
+					Headers: entry.route.Headers,
+				}, headHandler{entry.handler})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if !path.allowsMethod(http.MethodOptions) {
+			err := s.registerAutoMethod(path, Route{
+				Path:    path.path,
+				Methods: []string{http.MethodOptions},
+			}, optionsHandler{path})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *server) registerAutoMethod(path *routePath, route Route, handler http.Handler) error {
+	if err := path.router.Handle(route, handler); err != nil {
+		return fmt.Errorf("failed to register automatic %s handler for %q: %w", route.Methods[0], path.template, err)
+	}
+
+	path.add(route, handler)
 
+	return nil
 }
@@ -0,0 +1,111 @@
+package swrv_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+func newPingServer(host string, port uint16) swrv.Server {
+	return swrv.NewServer(host, port).
+		WithControllers(swrv.NewController("/ping", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewResponse()
+		})))
+}
+
+func TestServerRunAfterFailedListen(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := uint16(occupied.Addr().(*net.TCPAddr).Port)
+	server := newPingServer("127.0.0.1", port)
+
+	err = server.Run(context.Background(), nil)
+	if err == nil || errors.Is(err, swrv.ErrServerStarted) {
+		t.Fatalf("expected a listen error while the port is in use, got %v", err)
+	}
+
+	_ = occupied.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() { stopped <- server.Run(ctx, nil) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Addr() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start after the port was freed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = server.Run(context.Background(), nil); !errors.Is(err, swrv.ErrServerStarted) {
+		t.Errorf("expected ErrServerStarted starting a running server, got %v", err)
+	}
+
+	cancel()
+
+	if err = <-stopped; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
+
+func TestServerConcurrentStart(t *testing.T) {
+	server := newPingServer("", 0)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	// Build the handler while the server is starting.
+	var wait sync.WaitGroup
+	wait.Add(1)
+
+	go func() {
+		defer wait.Done()
+		_ = server.Handler()
+	}()
+
+	go func() { stopped <- server.Serve(ctx, listener) }()
+
+	wait.Wait()
+	cancel()
+
+	if err = <-stopped; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
+
+func TestServerGorillaTemplateError(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithControllers(swrv.NewController("/users/{id:[0-9]+}", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+			return swrv.NewResponse()
+		})))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = server.Serve(context.Background(), listener)
+	if err == nil || !strings.Contains(err.Error(), "swrvgorilla.NewRouter()") {
+		t.Fatalf("expected an error pointing at the gorilla router, got %v", err)
+	}
+
+	if err = server.Serve(context.Background(), listener); err == nil || errors.Is(err, swrv.ErrServerStarted) {
+		t.Errorf("expected the registration error to be returned again, got %v", err)
+	}
+}
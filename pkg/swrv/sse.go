@@ -0,0 +1,350 @@
+package swrv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEKeepAlive is the interval at which keep-alive comments are sent on
+// an SSEResponse stream with no configured keep-alive interval.
+const DefaultSSEKeepAlive = 15 * time.Second
+
+// ErrInvalidSSEEvent is returned by SSEStream.Send when the given event's ID or
+// Event fields contain a line break.
+var ErrInvalidSSEEvent = errors.New("invalid server-sent event")
+
+// SSEEvent is a single Server-Sent Event that may be sent to the client through
+// an SSEResponse.
+type SSEEvent struct {
+	// ID sets the client's last event ID.  A client that reconnects after being
+	// disconnected will send the last event ID it received in the
+	// Last-Event-ID request header.
+	//
+	// If empty, no id field is sent and the client's last event ID is
+	// unchanged.
+	ID string
+
+	// Event is the event type.
+	//
+	// If empty, the client will dispatch the event as a "message" event.
+	Event string
+
+	// Data is the event payload.  Data containing line breaks is sent as
+	// multiple data fields, which the client will join back together.
+	Data string
+
+	// Retry, if greater than zero, sets the time the client should wait before
+	// attempting to reconnect after the connection is lost.
+	Retry time.Duration
+}
+
+// SSEStream is used by the function passed to NewSSEResponseFunc to send events
+// to the client.
+type SSEStream interface {
+	// Send writes the given event to the client and flushes it.
+	//
+	// If the client has disconnected or the event could not be written, an
+	// error is returned and the stream's context is cancelled.
+	Send(event SSEEvent) error
+
+	// LastEventID returns the value of the request's Last-Event-ID header, which
+	// is set by clients that are reconnecting to the stream.
+	LastEventID() string
+
+	// Context returns a context that is cancelled when the client disconnects
+	// or the stream can no longer be written to.
+	Context() context.Context
+}
+
+// SSEResponse is a Response that streams Server-Sent Events to the client using
+// the "text/event-stream" content type.
+//
+// The events are written once the response has passed through the response
+// filters.  Each event is flushed to the client as soon as it has been
+// written, and keep-alive comments are sent while the stream is idle so that
+// intermediaries do not close the connection.
+//
+// The stream ends when its event source is exhausted or the client
+// disconnects.  Any write timeout configured on the Server is lifted for the
+// duration of the stream.
+type SSEResponse interface {
+	Response
+
+	// WithKeepAlive sets the interval at which keep-alive comments will be sent
+	// while the stream is idle.
+	//
+	// A negative interval disables keep-alive comments.  If unset, or if 0 is
+	// passed, DefaultSSEKeepAlive will be used.
+	WithKeepAlive(interval time.Duration) SSEResponse
+}
+
+// NewSSEResponse returns a new SSEResponse that sends each event received from
+// the given channel to the client, until the channel is closed or the client
+// disconnects.
+//
+// Handlers resuming a stream for a reconnecting client may read the ID of the
+// last event that client received from the HeaderLastEventID request header.
+//
+// Example:
+//
+//	events := make(chan swrv.SSEEvent)
+//	go publish(request.GetHeader(swrv.HeaderLastEventID), events)
+//	return swrv.NewSSEResponse(events)
+func NewSSEResponse(events <-chan SSEEvent) SSEResponse {
+	return NewSSEResponseFunc(func(stream SSEStream) error {
+		for {
+			select {
+			case <-stream.Context().Done():
+				return nil
+			case event, ok := <-events:
+				if !ok {
+					return nil
+				}
+
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+// NewSSEResponseFunc returns a new SSEResponse whose events are sent by the
+// given function.
+//
+// The function is called once the response headers have been sent, and the
+// stream ends when it returns.  The function should return promptly once the
+// stream's context has been cancelled.
+//
+// Example:
+//
+//	return swrv.NewSSEResponseFunc(func(stream swrv.SSEStream) error {
+//	  for update := range updatesSince(stream.LastEventID()) {
+//	    if err := stream.Send(swrv.SSEEvent{ID: update.ID, Data: update.JSON}); err != nil {
+//	      return err
+//	    }
+//	  }
+//	  return nil
+//	})
+func NewSSEResponseFunc(fn func(stream SSEStream) error) SSEResponse {
+	stream := &eventStream{source: fn}
+
+	return &sseResponse{
+		Response: NewResponse().
+			WithBody(stream).
+			WithHeader(HeaderContentType, ContentTypeTextEventStream).
+			WithHeader(HeaderCacheControl, "no-cache"),
+		stream: stream,
+	}
+}
+
+type sseResponse struct {
+	Response
+	stream *eventStream
+}
+
+func (s *sseResponse) WithKeepAlive(interval time.Duration) SSEResponse {
+	s.stream.keepAlive = interval
+	return s
+}
+
+// eventStream is the body of an SSEResponse.
+type eventStream struct {
+	source    func(stream SSEStream) error
+	keepAlive time.Duration
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// writeEventStream writes the given event stream response body to the client.
+func (c controller) writeEventStream(writer http.ResponseWriter, request Request, code int, body *eventStream) {
+	controller := http.NewResponseController(writer)
+
+	// Streams are long-lived, so must not be cut off by the server's write
+	// timeout.
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	writer.WriteHeader(code)
+
+	if request.Method() == http.MethodHead || !bodyAllowed(code) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(request.Context())
+	defer cancel()
+
+	stream := &sseStream{
+		writer:      writer,
+		controller:  controller,
+		ctx:         ctx,
+		cancel:      cancel,
+		lastEventID: request.GetHeader(HeaderLastEventID),
+	}
+
+	// Send the headers immediately so the client knows the stream is open.
+	stream.lock.Lock()
+	err := stream.flush()
+	stream.lock.Unlock()
+
+	if err != nil {
+		c.logger.Debug("failed to open event stream", "error", err)
+		return
+	}
+
+	interval := body.keepAlive
+	if interval == 0 {
+		interval = DefaultSSEKeepAlive
+	}
+
+	var wait sync.WaitGroup
+
+	if interval > 0 {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			stream.keepAlive(interval)
+		}()
+	}
+
+	err = body.source(stream)
+
+	cancel()
+	wait.Wait()
+
+	switch {
+	case stream.err != nil:
+		c.logger.Debug("event stream closed after a failed write", "error", stream.err)
+	case err != nil && request.Context().Err() == nil:
+		c.logger.Error("event stream source failed", "error", err)
+	}
+}
+
+// sseStream implements SSEStream over an http.ResponseWriter.
+type sseStream struct {
+	lock        sync.Mutex
+	writer      http.ResponseWriter
+	controller  *http.ResponseController
+	ctx         context.Context
+	cancel      context.CancelFunc
+	lastEventID string
+	buffer      []byte
+
+	// err is the first error encountered writing to the client.
+	err error
+}
+
+func (s *sseStream) Send(event SSEEvent) error {
+	if strings.ContainsAny(event.ID, "\r\n\x00") || strings.ContainsAny(event.Event, "\r\n") {
+		return ErrInvalidSSEEvent
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	s.buffer = appendSSEEvent(s.buffer[:0], event)
+
+	return s.flush()
+}
+
+func (s *sseStream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *sseStream) Context() context.Context {
+	return s.ctx
+}
+
+// keepAlive sends a comment line at the given interval until the stream's
+// context is cancelled.
+func (s *sseStream) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.lock.Lock()
+			if s.err == nil {
+				s.buffer = append(s.buffer[:0], ":\n\n"...)
+				_ = s.flush()
+			}
+			s.lock.Unlock()
+		}
+	}
+}
+
+// flush writes the buffered data to the client and flushes the response.
+//
+// Must be called with the stream's lock held.
+func (s *sseStream) flush() error {
+	if len(s.buffer) > 0 {
+		if _, err := s.writer.Write(s.buffer); err != nil {
+			return s.fail(err)
+		}
+	}
+
+	if err := s.controller.Flush(); err != nil {
+		return s.fail(err)
+	}
+
+	return nil
+}
+
+// fail records the given write error and cancels the stream.
+func (s *sseStream) fail(err error) error {
+	s.err = err
+	s.cancel()
+	return err
+}
+
+// appendSSEEvent appends the wire format of the given event to the given
+// buffer.
+func appendSSEEvent(buffer []byte, event SSEEvent) []byte {
+	if len(event.ID) > 0 {
+		buffer = append(buffer, "id: "...)
+		buffer = append(buffer, event.ID...)
+		buffer = append(buffer, '\n')
+	}
+
+	if len(event.Event) > 0 {
+		buffer = append(buffer, "event: "...)
+		buffer = append(buffer, event.Event...)
+		buffer = append(buffer, '\n')
+	}
+
+	if event.Retry > 0 {
+		buffer = append(buffer, "retry: "...)
+		buffer = strconv.AppendInt(buffer, event.Retry.Milliseconds(), 10)
+		buffer = append(buffer, '\n')
+	}
+
+	// Events with neither data nor a type only update the client's last event
+	// ID or retry time.
+	if len(event.Data) > 0 || len(event.Event) > 0 {
+		data := strings.ReplaceAll(event.Data, "\r\n", "\n")
+		data = strings.ReplaceAll(data, "\r", "\n")
+
+		for _, line := range strings.Split(data, "\n") {
+			buffer = append(buffer, "data: "...)
+			buffer = append(buffer, line...)
+			buffer = append(buffer, '\n')
+		}
+	}
+
+	return append(buffer, '\n')
+}
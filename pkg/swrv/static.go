@@ -0,0 +1,308 @@
+package swrv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// staticIndexFile is the file served for requests to a directory.
+const staticIndexFile = "index.html"
+
+// staticContentTypes maps file extensions to the content types used for static
+// files.
+var staticContentTypes = map[string]string{
+	".css":    ContentTypeTextCSS,
+	".csv":    ContentTypeTextCSV,
+	".htm":    ContentTypeTextHTML,
+	".html":   ContentTypeTextHTML,
+	".js":     ContentTypeTextJavascript,
+	".mjs":    ContentTypeTextJavascript,
+	".txt":    ContentTypeTextPlain,
+	".gz":     ContentTypeApplicationGZip,
+	".json":   ContentTypeApplicationJSON,
+	".jsonld": ContentTypeApplicationLDJSON,
+	".ndjson": ContentTypeApplicationNDJSON,
+	".pdf":    ContentTypeApplicationPDF,
+	".rtf":    ContentTypeApplicationRTF,
+	".xml":    ContentTypeApplicationXML,
+	".zip":    ContentTypeApplicationZip,
+	".aac":    ContentTypeAudioAAC,
+	".mid":    ContentTypeAudioMidi,
+	".midi":   ContentTypeAudioMidi,
+	".mp3":    ContentTypeAudioMP3,
+	".oga":    ContentTypeAudioOGG,
+	".weba":   ContentTypeAudioWebA,
+	".otf":    ContentTypeFontOTF,
+	".ttf":    ContentTypeFontTTF,
+	".bmp":    ContentTypeImageBMP,
+	".gif":    ContentTypeImageGif,
+	".jpeg":   ContentTypeImageJpeg,
+	".jpg":    ContentTypeImageJpeg,
+	".svg":    ContentTypeImageSVG,
+	".tif":    ContentTypeImageTiff,
+	".tiff":   ContentTypeImageTiff,
+	".webp":   ContentTypeImageWebP,
+	".mp4":    ContentTypeVideoMP4,
+	".mpeg":   ContentTypeVideoMpeg,
+	".mpg":    ContentTypeVideoMpeg,
+	".webm":   ContentTypeVideoWebM,
+}
+
+// StaticControllerSpec is a ControllerSpec for a controller that serves static
+// files.
+//
+// As the ControllerSpec methods return a ControllerSpec, the static file
+// specific options should be set before any other options.
+type StaticControllerSpec interface {
+	ControllerSpec
+
+	// WithSPAFallback configures the controller to serve the given file, for
+	// example "index.html", in place of files that do not exist, so that a
+	// single page application may handle its own client-side routes.
+	//
+	// The fallback is only served for request paths whose final segment has no
+	// file extension, so requests for missing assets such as "/app/main.js"
+	// are still answered with a 404 Not Found error.
+	WithSPAFallback(file string) StaticControllerSpec
+}
+
+// NewStaticController returns a new StaticControllerSpec which may be used to
+// construct a controller that serves the files in the given file system, such
+// as an embed.FS or an os.DirFS, beneath the given path prefix.
+//
+// The built controller is a regular controller, so the global and controller
+// specific request and response filters are applied to static file requests.
+//
+// Requests for a directory are answered with the directory's index.html file,
+// and directory listings are never served.  Content-Type headers are set from
+// the file extension, and Last-Modified and ETag headers are set so that
+// clients may make conditional requests.  Range requests are supported for
+// files that implement io.ReadSeeker, as the files of an embed.FS or os.DirFS
+// do, see NewFileResponse.  If a file has a precompressed ".gz" sibling and
+// the client accepts the gzip content coding, the precompressed file is served
+// instead.
+//
+// The controller is registered at the path prefix followed by a "{path...}"
+// catch-all wildcard, for example "/assets/{path...}".  All of the Routers
+// provided by swrv and swrvgorilla support catch-all wildcards, and custom
+// Routers are required to support them or reject the route, see Route.
+//
+// Example:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	files, _ := fs.Sub(dist, "dist")
+//	server.WithControllers(swrv.NewStaticController("/", files).
+//	  WithSPAFallback("index.html"))
+func NewStaticController(prefix string, files fs.FS) StaticControllerSpec {
+	handler := &staticHandler{files: files}
+
+	return &staticControllerSpec{
+		controllerSpec: &controllerSpec{
+			path:    strings.TrimSuffix(prefix, "/") + "/{path...}",
+			methods: []string{http.MethodGet},
+			handler: WrapRequestHandlerE(handler),
+			headers: make(map[string]string),
+		},
+		handler: handler,
+	}
+}
+
+type staticControllerSpec struct {
+	*controllerSpec
+	handler *staticHandler
+}
+
+func (s *staticControllerSpec) WithSPAFallback(file string) StaticControllerSpec {
+	s.handler.fallback = strings.TrimPrefix(file, "/")
+	return s
+}
+
+// staticHandler is the RequestHandlerE for controllers built from
+// NewStaticController.
+type staticHandler struct {
+	files    fs.FS
+	fallback string
+
+	// etags caches the content hash entity tags of files without a modification
+	// time, keyed by file name.
+	etags sync.Map
+}
+
+func (s *staticHandler) HandleRequestE(request Request) (Response, error) {
+	name := path.Clean("/" + request.URIParam("path"))[1:]
+	if len(name) == 0 {
+		name = "."
+	}
+
+	file, info, err := s.open(name)
+
+	if errors.Is(err, fs.ErrNotExist) && len(s.fallback) > 0 && path.Ext(name) == "" {
+		name = s.fallback
+		file, info, err = s.open(name)
+	}
+
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			return nil, NewHTTPError(http.StatusNotFound, "", err)
+		}
+
+		return nil, err
+	}
+
+	if info.IsDir() {
+		_ = file.Close()
+
+		// Redirect to the directory path with a trailing slash so that relative
+		// links in the index file resolve correctly.
+		if raw := request.Raw(); !strings.HasSuffix(raw.URL.Path, "/") {
+			location := raw.URL.Path + "/"
+			if len(raw.URL.RawQuery) > 0 {
+				location += "?" + raw.URL.RawQuery
+			}
+
+			return NewResponse().WithCode(http.StatusMovedPermanently).WithHeader(HeaderLocation, location), nil
+		}
+
+		name = path.Join(name, staticIndexFile)
+
+		if file, info, err = s.open(name); err != nil || info.IsDir() {
+			if err == nil {
+				_ = file.Close()
+			}
+
+			return nil, NewHTTPError(http.StatusNotFound, "", err)
+		}
+	}
+
+	contentType := staticContentType(name)
+	response := NewResponse()
+
+	// Serve the precompressed sibling of the file, if it has one and the client
+	// will accept it.
+	if gzFile, gzInfo, err := s.open(name + ".gz"); err == nil {
+		if !gzInfo.IsDir() && negotiateEncoding(request.GetHeaders(HeaderAcceptEncoding), []string{"gzip"}) == "gzip" {
+			_ = file.Close()
+			file, info = gzFile, gzInfo
+			name += ".gz"
+			response.WithHeader(HeaderContentEncoding, "gzip")
+		} else {
+			_ = gzFile.Close()
+		}
+
+		response.WithHeader(HeaderVary, HeaderAcceptEncoding)
+	}
+
+	etag, err := s.etag(name, info)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	response.WithHeader(HeaderETag, etag)
+
+	// Files that can seek are served as file responses, which also evaluate the
+	// conditional request headers and answer range requests.
+	if content, ok := file.(io.ReadSeeker); ok {
+		return response.
+			WithHeader(HeaderContentType, contentType).
+			WithBody(&fileBody{content: content, modified: info.ModTime()}), nil
+	}
+
+	if modified := info.ModTime(); !modified.IsZero() {
+		response.WithHeader(HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	switch checkPreconditions(request.Raw(), etag, info.ModTime()) {
+	case http.StatusNotModified:
+		_ = file.Close()
+		return response.WithCode(http.StatusNotModified), nil
+	case http.StatusPreconditionFailed:
+		_ = file.Close()
+		return nil, NewHTTPError(http.StatusPreconditionFailed, "", nil)
+	}
+
+	return response.
+		WithHeader(HeaderContentType, contentType).
+		WithHeader(HeaderContentLength, strconv.FormatInt(info.Size(), 10)).
+		WithBody(file), nil
+}
+
+// open opens the named file and returns it along with its FileInfo.
+func (s *staticHandler) open(name string) (fs.File, fs.FileInfo, error) {
+	file, err := s.files.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+
+	return file, info, nil
+}
+
+// etag returns the entity tag for the named file.
+//
+// Files with a modification time are tagged using their modification time and
+// size.  Files without one, such as those in an embed.FS, are tagged using a
+// hash of their content, which is computed once and cached.
+func (s *staticHandler) etag(name string, info fs.FileInfo) (string, error) {
+	if modified := info.ModTime(); !modified.IsZero() {
+		return "\"" + strconv.FormatInt(modified.UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36) + "\"", nil
+	}
+
+	if cached, ok := s.etags.Load(name); ok {
+		return cached.(string), nil
+	}
+
+	// Hash a separate handle so that the file being served is not consumed.
+	file, err := s.files.Open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	etag := "\"" + hex.EncodeToString(hash.Sum(nil)[:12]) + "\""
+	s.etags.Store(name, etag)
+
+	return etag, nil
+}
+
+// staticContentType returns the content type for the file with the given name,
+// based on its extension.
+func staticContentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+
+	if contentType, ok := staticContentTypes[ext]; ok {
+		if strings.HasPrefix(contentType, "text/") {
+			return contentType + "; charset=utf-8"
+		}
+
+		return contentType
+	}
+
+	if contentType := mime.TypeByExtension(ext); len(contentType) > 0 {
+		return contentType
+	}
+
+	return ContentTypeApplicationOctetStream
+}
@@ -0,0 +1,97 @@
+package swrv_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvgorilla"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+func spaFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>app</h1>")},
+		"assets/app.css": {Data: []byte("body{}")},
+	}
+}
+
+func TestStaticControllerRootSPA(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithControllers(
+			swrv.NewController("/api/ping", swrv.RequestHandlerFunc(func(swrv.Request) swrv.Response {
+				return swrv.NewResponse().WithBody("pong")
+			})),
+			swrv.NewStaticController("/", spaFiles()).WithSPAFallback("index.html"),
+		)
+
+	client := swrvtest.New(server)
+
+	client.GET("/").Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentType, "text/html; charset=utf-8").
+		Body("<h1>app</h1>")
+
+	client.GET("/assets/app.css").Expect(t).
+		Status(http.StatusOK).
+		Header(swrv.HeaderContentType, "text/css; charset=utf-8").
+		Body("body{}")
+
+	client.GET("/users/42/settings").Expect(t).
+		Status(http.StatusOK).
+		Body("<h1>app</h1>")
+
+	client.GET("/assets/missing.js").Expect(t).
+		Status(http.StatusNotFound)
+
+	client.GET("/api/ping").Expect(t).
+		Status(http.StatusOK).
+		Body("pong")
+}
+
+func TestServeMuxRouterNotFound(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithControllers(swrv.NewStaticController("/assets", spaFiles()))
+
+	swrvtest.New(server).GET("/missing").Expect(t).
+		Status(http.StatusNotFound)
+}
+
+func TestStaticControllerGorillaRouter(t *testing.T) {
+	server := swrv.NewServer("127.0.0.1", 0).
+		WithControllers(swrv.NewStaticController("/static", spaFiles()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() { stopped <- server.Run(ctx, swrvgorilla.NewRouter()) }()
+
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Addr() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	response, err := http.Get("http://" + server.Addr().String() + "/static/assets/app.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || string(body) != "body{}" {
+		t.Errorf("expected the nested asset to be served, got status %d body %q", response.StatusCode, body)
+	}
+}
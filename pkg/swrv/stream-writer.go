@@ -0,0 +1,193 @@
+package swrv
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StreamWriter is used by the function passed to Response.WithBodyWriter to
+// write a response body directly to the client.
+//
+// The response status and headers are sent on the first call to Write or
+// Flush.
+type StreamWriter interface {
+	// Write writes the given data to the response body.
+	//
+	// Written data may be buffered by the server before it is sent to the
+	// client.  Once the client has disconnected or a write has failed, every
+	// subsequent call to Write or Flush returns the same error.
+	io.Writer
+
+	// Flush sends any buffered response data to the client.
+	Flush() error
+
+	// Context returns the request's context, which is cancelled when the client
+	// disconnects.
+	Context() context.Context
+}
+
+// bodyWriter is the body of a Response built with WithBodyWriter.
+type bodyWriter func(writer StreamWriter) error
+
+// streamWriter implements StreamWriter over an http.ResponseWriter.
+type streamWriter struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+	ctx        context.Context
+	code       int
+	started    bool
+
+	// err is the first error encountered writing to the client.
+	err error
+}
+
+func (s *streamWriter) Write(b []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	s.start()
+
+	n, err := s.writer.Write(b)
+	if err != nil {
+		s.err = err
+	}
+
+	return n, err
+}
+
+func (s *streamWriter) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.start()
+
+	if err := s.controller.Flush(); err != nil {
+		s.err = err
+	}
+
+	return s.err
+}
+
+func (s *streamWriter) Context() context.Context {
+	return s.ctx
+}
+
+// start writes the response status, if it has not already been written.
+func (s *streamWriter) start() {
+	if !s.started {
+		s.started = true
+		s.writer.WriteHeader(s.code)
+	}
+}
+
+// writeBodyWriter calls the given body writer function to write the response
+// body.
+//
+// If the function fails before anything has been written, the error is
+// converted into an error response which is written instead.
+//
+// For HEAD requests the function is not called, and only the response status
+// and headers are written.
+func (c controller) writeBodyWriter(writer http.ResponseWriter, request Request, code int, body bodyWriter) {
+	if request.Method() == http.MethodHead {
+		writer.WriteHeader(code)
+		return
+	}
+
+	stream := &streamWriter{
+		writer:     writer,
+		controller: http.NewResponseController(writer),
+		ctx:        request.Context(),
+		code:       code,
+	}
+
+	err := body(stream)
+
+	if err == nil {
+		stream.start()
+		return
+	}
+
+	if stream.started || request.Context().Err() != nil {
+		if stream.err != nil || request.Context().Err() != nil {
+			c.logger.Debug("response body writer stopped", "error", err)
+			return
+		}
+
+		// The response can no longer be replaced, all that can be done is to abort
+		// the connection so the client sees an incomplete response.
+		c.logger.Error("response body writer failed after the response was started, aborting connection", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	// Nothing has been sent yet, so an error response may still be returned in
+	// place of the streamed body.
+	c.replaceResponse(writer, request, c.resolve(request, nil, err))
+}
+
+// writeReader copies the given reader to the response body, closing the reader
+// afterwards if it is an io.ReadCloser.
+//
+// For HEAD requests the reader is closed without being read.
+func (c controller) writeReader(writer http.ResponseWriter, request Request, reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				c.logger.Error("failed to close body ReadCloser", "error", err)
+			}
+		}()
+	}
+
+	if request.Method() == http.MethodHead {
+		return
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		c.logger.Error("failed to copy body from reader to response writer", "error", err)
+	}
+}
+
+// setReaderLength sets the Content-Length header for a response with the given
+// status code and body, if the header has not already been set and the body is
+// an in-memory reader whose remaining length is known without reading it.
+func setReaderLength(header http.Header, code int, reader io.Reader) {
+	if len(header.Get(HeaderContentLength)) > 0 || !bodyAllowed(code) {
+		return
+	}
+
+	var length int
+
+	switch reader := reader.(type) {
+	case *bytes.Buffer:
+		length = reader.Len()
+	case *bytes.Reader:
+		length = reader.Len()
+	case *strings.Reader:
+		length = reader.Len()
+	default:
+		return
+	}
+
+	header.Set(HeaderContentLength, strconv.Itoa(length))
+}
+
+// complete runs the given response's OnComplete callback, if it has one, once
+// all response data has been sent to the client.
+func (c controller) complete(writer http.ResponseWriter, response Response) {
+	fn := response.GetOnComplete()
+	if fn == nil {
+		return
+	}
+
+	if err := http.NewResponseController(writer).Flush(); err != nil {
+		c.logger.Debug("failed to flush response before calling OnComplete", "error", err)
+	}
+
+	fn()
+}
@@ -0,0 +1,486 @@
+package swrv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocketMessageType identifies the type of a WebSocket data message.
+type WebSocketMessageType uint8
+
+const (
+	// WebSocketText identifies a UTF-8 encoded text message.
+	WebSocketText WebSocketMessageType = 1
+
+	// WebSocketBinary identifies a binary message.
+	WebSocketBinary WebSocketMessageType = 2
+)
+
+// WebSocket close codes, as defined by RFC 6455.
+const (
+	WebSocketCloseNormal             = 1000
+	WebSocketCloseGoingAway          = 1001
+	WebSocketCloseProtocolError      = 1002
+	WebSocketCloseUnsupportedData    = 1003
+	WebSocketCloseNoStatus           = 1005
+	WebSocketCloseAbnormal           = 1006
+	WebSocketCloseInvalidPayload     = 1007
+	WebSocketClosePolicyViolation    = 1008
+	WebSocketCloseMessageTooBig      = 1009
+	WebSocketCloseMandatoryExtension = 1010
+	WebSocketCloseInternalError      = 1011
+)
+
+// ErrWebSocketClosed is returned when attempting to write to a WebSocket
+// connection after a close frame has been sent.
+var ErrWebSocketClosed = errors.New("websocket connection closed")
+
+// ErrInvalidWebSocketMessage is returned when attempting to write a WebSocket
+// message or control frame that is not valid, such as a text message that is
+// not valid UTF-8 or a ping with a payload longer than 125 bytes.
+var ErrInvalidWebSocketMessage = errors.New("invalid websocket message")
+
+// WebSocketCloseError is returned by WebSocketConn.ReadMessage once the
+// connection has been closed, describing the close code and reason.
+type WebSocketCloseError struct {
+	// Code is the close code sent by the client, or the close code sent by the
+	// server if the client violated the protocol or the message size limit.
+	//
+	// If the client sent a close frame without a code, Code will be
+	// WebSocketCloseNoStatus.  If the connection was lost without a close
+	// frame, Code will be WebSocketCloseAbnormal.
+	Code int
+
+	// Reason is the close reason.
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	if len(e.Reason) > 0 {
+		return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+	}
+
+	return fmt.Sprintf("websocket closed with code %d", e.Code)
+}
+
+// WebSocketConn is a WebSocket connection accepted by a controller built from
+// NewWebSocketController.
+//
+// ReadMessage may be called by one goroutine at a time, while the write
+// methods may be called concurrently with each other and with ReadMessage.
+type WebSocketConn interface {
+	// Subprotocol returns the subprotocol selected during the handshake, or an
+	// empty string if no subprotocol was selected.
+	Subprotocol() string
+
+	// ReadMessage reads the next text or binary message from the client,
+	// reassembling fragmented messages.
+	//
+	// Ping frames received from the client are answered automatically, and pong
+	// frames are passed to the handler set with SetPongHandler.
+	//
+	// Once the connection has been closed, either by the client or because the
+	// client violated the protocol or the maximum message size, a
+	// *WebSocketCloseError is returned.
+	ReadMessage() (WebSocketMessageType, []byte, error)
+
+	// WriteMessage writes a single text or binary message to the client.
+	WriteMessage(kind WebSocketMessageType, data []byte) error
+
+	// Ping sends a ping frame with the given payload, which may be up to 125
+	// bytes long, to the client.
+	Ping(data []byte) error
+
+	// SetPongHandler sets a function that will be called from ReadMessage with
+	// the payload of each pong frame received from the client.
+	SetPongHandler(fn func(data []byte))
+
+	// Close sends a close frame with the given close code and reason to the
+	// client, then closes the connection.
+	//
+	// Calling Close on a connection that has already been closed returns
+	// ErrWebSocketClosed.
+	Close(code int, reason string) error
+
+	// SetReadDeadline sets the deadline for future ReadMessage calls.  A zero
+	// value means reads will not time out.
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline for future write calls.  A zero value
+	// means writes will not time out.
+	SetWriteDeadline(t time.Time) error
+}
+
+// WebSocket frame opcodes.
+const (
+	wsOpContinuation byte = 0x0
+	wsOpText         byte = 0x1
+	wsOpBinary       byte = 0x2
+	wsOpClose        byte = 0x8
+	wsOpPing         byte = 0x9
+	wsOpPong         byte = 0xA
+)
+
+// wsMaxControlPayload is the maximum payload length of a control frame.
+const wsMaxControlPayload = 125
+
+func newWebSocketConn(conn net.Conn, reader *bufio.Reader, subprotocol string, maxMessage int64) *webSocketConn {
+	return &webSocketConn{
+		conn:        conn,
+		reader:      reader,
+		subprotocol: subprotocol,
+		maxMessage:  maxMessage,
+	}
+}
+
+type webSocketConn struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	subprotocol string
+
+	// maxMessage is the maximum size of messages read from the client, or 0 if
+	// messages are unlimited.
+	maxMessage int64
+
+	onPong func(data []byte)
+
+	// readErr is the error returned by every ReadMessage call once the
+	// connection has failed or been closed.
+	readErr error
+
+	writeLock sync.Mutex
+	closeSent bool
+}
+
+// wsFrameHeader is the parsed header of a frame received from the client.
+type wsFrameHeader struct {
+	fin    bool
+	opcode byte
+	length int64
+	mask   [4]byte
+}
+
+func (w *webSocketConn) Subprotocol() string {
+	return w.subprotocol
+}
+
+func (w *webSocketConn) SetPongHandler(fn func(data []byte)) {
+	w.onPong = fn
+}
+
+func (w *webSocketConn) SetReadDeadline(t time.Time) error {
+	return w.conn.SetReadDeadline(t)
+}
+
+func (w *webSocketConn) SetWriteDeadline(t time.Time) error {
+	return w.conn.SetWriteDeadline(t)
+}
+
+func (w *webSocketConn) ReadMessage() (WebSocketMessageType, []byte, error) {
+	if w.readErr != nil {
+		return 0, nil, w.readErr
+	}
+
+	var message bytes.Buffer
+	var kind byte
+
+	for {
+		header, err := w.readFrameHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if header.opcode >= wsOpClose {
+			if err = w.readControlFrame(header); err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		switch {
+		case header.opcode == wsOpText || header.opcode == wsOpBinary:
+			if kind != 0 {
+				return 0, nil, w.fail(WebSocketCloseProtocolError, "expected a continuation frame")
+			}
+
+			kind = header.opcode
+
+		case header.opcode == wsOpContinuation:
+			if kind == 0 {
+				return 0, nil, w.fail(WebSocketCloseProtocolError, "unexpected continuation frame")
+			}
+
+		default:
+			return 0, nil, w.fail(WebSocketCloseProtocolError, "unknown opcode")
+		}
+
+		if w.maxMessage > 0 && int64(message.Len())+header.length > w.maxMessage {
+			return 0, nil, w.fail(WebSocketCloseMessageTooBig, "message too large")
+		}
+
+		start := message.Len()
+
+		if _, err = io.CopyN(&message, w.reader, header.length); err != nil {
+			return 0, nil, w.lost(err)
+		}
+
+		unmask(message.Bytes()[start:], header.mask)
+
+		if !header.fin {
+			continue
+		}
+
+		if kind == wsOpText && !utf8.Valid(message.Bytes()) {
+			return 0, nil, w.fail(WebSocketCloseInvalidPayload, "invalid UTF-8 in text message")
+		}
+
+		return WebSocketMessageType(kind), message.Bytes(), nil
+	}
+}
+
+// readFrameHeader reads and validates the header of the next frame received
+// from the client.
+func (w *webSocketConn) readFrameHeader() (header wsFrameHeader, err error) {
+	var buffer [8]byte
+
+	if _, err = io.ReadFull(w.reader, buffer[:2]); err != nil {
+		return header, w.lost(err)
+	}
+
+	header.fin = buffer[0]&0x80 != 0
+	header.opcode = buffer[0] & 0x0F
+	header.length = int64(buffer[1] & 0x7F)
+
+	// No extensions are negotiated, so the reserved bits must be unset.
+	if buffer[0]&0x70 != 0 {
+		return header, w.fail(WebSocketCloseProtocolError, "reserved bits set")
+	}
+
+	if buffer[1]&0x80 == 0 {
+		return header, w.fail(WebSocketCloseProtocolError, "client frames must be masked")
+	}
+
+	switch header.length {
+	case 126:
+		if _, err = io.ReadFull(w.reader, buffer[:2]); err != nil {
+			return header, w.lost(err)
+		}
+
+		header.length = int64(binary.BigEndian.Uint16(buffer[:2]))
+
+	case 127:
+		if _, err = io.ReadFull(w.reader, buffer[:8]); err != nil {
+			return header, w.lost(err)
+		}
+
+		if buffer[0]&0x80 != 0 {
+			return header, w.fail(WebSocketCloseProtocolError, "invalid frame length")
+		}
+
+		header.length = int64(binary.BigEndian.Uint64(buffer[:8]))
+	}
+
+	if _, err = io.ReadFull(w.reader, header.mask[:]); err != nil {
+		return header, w.lost(err)
+	}
+
+	if header.opcode >= wsOpClose && (!header.fin || header.length > wsMaxControlPayload) {
+		return header, w.fail(WebSocketCloseProtocolError, "invalid control frame")
+	}
+
+	return header, nil
+}
+
+// readControlFrame reads the payload of the control frame with the given header
+// and handles it.
+func (w *webSocketConn) readControlFrame(header wsFrameHeader) error {
+	payload := make([]byte, header.length)
+
+	if _, err := io.ReadFull(w.reader, payload); err != nil {
+		return w.lost(err)
+	}
+
+	unmask(payload, header.mask)
+
+	switch header.opcode {
+	case wsOpPing:
+		if err := w.writeFrame(wsOpPong, payload); err != nil && !errors.Is(err, ErrWebSocketClosed) {
+			return w.lost(err)
+		}
+
+	case wsOpPong:
+		if w.onPong != nil {
+			w.onPong(payload)
+		}
+
+	case wsOpClose:
+		return w.readClose(payload)
+
+	default:
+		return w.fail(WebSocketCloseProtocolError, "unknown opcode")
+	}
+
+	return nil
+}
+
+// readClose handles a close frame with the given payload received from the
+// client, replying with a close frame if one has not already been sent.
+func (w *webSocketConn) readClose(payload []byte) error {
+	closeErr := &WebSocketCloseError{Code: WebSocketCloseNoStatus}
+
+	switch {
+	case len(payload) == 1:
+		return w.fail(WebSocketCloseProtocolError, "invalid close frame")
+
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+
+		if !validCloseCode(closeErr.Code) {
+			return w.fail(WebSocketCloseProtocolError, "invalid close code")
+		}
+
+		if !utf8.ValidString(closeErr.Reason) {
+			return w.fail(WebSocketCloseInvalidPayload, "invalid UTF-8 in close reason")
+		}
+	}
+
+	// Echo the client's close code to complete the closing handshake.
+	if closeErr.Code == WebSocketCloseNoStatus {
+		_ = w.writeFrame(wsOpClose, nil)
+	} else {
+		_ = w.writeFrame(wsOpClose, payload[:2])
+	}
+
+	_ = w.conn.Close()
+
+	w.readErr = closeErr
+	return closeErr
+}
+
+// fail closes the connection with the given close code and reason after the
+// client has violated the protocol.
+func (w *webSocketConn) fail(code int, reason string) error {
+	_ = w.Close(code, reason)
+
+	w.readErr = &WebSocketCloseError{Code: code, Reason: reason}
+	return w.readErr
+}
+
+// lost records that the connection was lost, or a read failed, without a close
+// frame being received.
+func (w *webSocketConn) lost(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		w.readErr = &WebSocketCloseError{Code: WebSocketCloseAbnormal}
+	} else {
+		// Other errors, such as timeouts, may leave the connection part way
+		// through a frame, so it cannot be read from again.
+		w.readErr = err
+	}
+
+	return w.readErr
+}
+
+func (w *webSocketConn) WriteMessage(kind WebSocketMessageType, data []byte) error {
+	switch kind {
+	case WebSocketText:
+		if !utf8.Valid(data) {
+			return ErrInvalidWebSocketMessage
+		}
+	case WebSocketBinary:
+	default:
+		return ErrInvalidWebSocketMessage
+	}
+
+	return w.writeFrame(byte(kind), data)
+}
+
+func (w *webSocketConn) Ping(data []byte) error {
+	if len(data) > wsMaxControlPayload {
+		return ErrInvalidWebSocketMessage
+	}
+
+	return w.writeFrame(wsOpPing, data)
+}
+
+func (w *webSocketConn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	// Truncate overlong reasons without splitting a multi-byte character.
+	for len(payload) > wsMaxControlPayload || !utf8.Valid(payload[2:]) {
+		payload = payload[:min(len(payload)-1, wsMaxControlPayload)]
+	}
+
+	err := w.writeFrame(wsOpClose, payload)
+	if errors.Is(err, ErrWebSocketClosed) {
+		return err
+	}
+
+	if closeErr := w.conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// writeFrame writes a single, unfragmented frame with the given opcode and
+// payload to the client.
+func (w *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+
+	if w.closeSent {
+		return ErrWebSocketClosed
+	}
+
+	if opcode == wsOpClose {
+		w.closeSent = true
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | opcode
+
+	switch length := len(payload); {
+	case length <= wsMaxControlPayload:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(w.conn)
+	return err
+}
+
+// unmask applies the given masking key to the given frame payload.
+func unmask(payload []byte, mask [4]byte) {
+	for i := range payload {
+		payload[i] ^= mask[i&3]
+	}
+}
+
+// validCloseCode tests whether the given close code may be sent in a close
+// frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
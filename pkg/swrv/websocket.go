@@ -0,0 +1,331 @@
+package swrv
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultWebSocketMaxMessageSize is the maximum size, in bytes, of messages
+// read from a WebSocket connection with no configured maximum message size.
+const DefaultWebSocketMaxMessageSize = 1 << 20
+
+// webSocketGUID is the value appended to the client's handshake key to compute
+// the accept key, as defined by RFC 6455.
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketHandler handles WebSocket connections accepted by a controller built
+// from NewWebSocketController.
+type WebSocketHandler interface {
+	// HandleWebSocket is called with the upgrade request and the accepted
+	// WebSocket connection once the handshake has completed.
+	//
+	// The connection is closed once this method returns, with the close code
+	// WebSocketCloseNormal if nil is returned, or WebSocketCloseInternalError if
+	// an error is returned, unless the connection has already been closed.
+	HandleWebSocket(request Request, conn WebSocketConn) error
+}
+
+// WebSocketHandlerFunc is a function that implements the WebSocketHandler
+// interface.
+type WebSocketHandlerFunc func(request Request, conn WebSocketConn) error
+
+func (f WebSocketHandlerFunc) HandleWebSocket(request Request, conn WebSocketConn) error {
+	return f(request, conn)
+}
+
+// WebSocketControllerSpec is a ControllerSpec for a controller that accepts
+// WebSocket connections.
+//
+// As the ControllerSpec methods return a ControllerSpec, the WebSocket specific
+// options should be set before any other options.
+type WebSocketControllerSpec interface {
+	ControllerSpec
+
+	// WithSubprotocols sets the subprotocols supported by the controller, in
+	// order of preference.
+	//
+	// The first of the given subprotocols that is requested by the client in the
+	// Sec-WebSocket-Protocol header will be selected, and made available through
+	// WebSocketConn.Subprotocol.  If the client does not request any of the given
+	// subprotocols, the connection is accepted without a subprotocol.
+	WithSubprotocols(protocols ...string) WebSocketControllerSpec
+
+	// WithAllowedOrigins sets the origins, other than the origin of the server
+	// itself, from which browsers may open WebSocket connections.
+	//
+	// Origins may be given exactly, for example "https://example.com", or as a
+	// pattern containing a single "*" wildcard, for example
+	// "https://*.example.com".  The origin "*" allows connections from any
+	// origin.
+	//
+	// If unset, handshake requests with an Origin header whose host does not
+	// match the request's Host header are rejected with a 403 Forbidden error.
+	// Requests without an Origin header, which are not sent by browsers, are
+	// always allowed.
+	WithAllowedOrigins(origins ...string) WebSocketControllerSpec
+
+	// WithMaxMessageSize sets the maximum size, in bytes, of messages that will
+	// be read from the client.
+	//
+	// Connections from clients that send larger messages are closed with the
+	// close code WebSocketCloseMessageTooBig.
+	//
+	// A negative value disables the limit.  If unset, or if 0 is passed,
+	// DefaultWebSocketMaxMessageSize will be used.
+	WithMaxMessageSize(bytes int64) WebSocketControllerSpec
+}
+
+// NewWebSocketController returns a new WebSocketControllerSpec which may be
+// used to construct a controller that accepts RFC 6455 WebSocket connections at
+// the given path.
+//
+// The built controller runs the request filters like any other controller
+// before performing the WebSocket handshake, so a RequestFilter may be used to
+// authenticate the upgrade request.  Requests that are not valid WebSocket
+// handshake requests are answered with a 400 Bad Request or 426 Upgrade
+// Required error.
+//
+// Once the handshake has completed, the connection is taken over from the
+// Server, so the Server's timeouts no longer apply and the request's context
+// is not cancelled if the client disconnects.  Handlers should use the
+// connection's deadlines instead.
+//
+// The returned ControllerSpec is registered for the GET method.
+//
+// Example:
+//
+//	server.WithControllers(swrv.NewWebSocketController("/echo",
+//	  swrv.WebSocketHandlerFunc(func(request swrv.Request, conn swrv.WebSocketConn) error {
+//	    for {
+//	      kind, message, err := conn.ReadMessage()
+//	      if err != nil {
+//	        return nil
+//	      }
+//	      if err = conn.WriteMessage(kind, message); err != nil {
+//	        return err
+//	      }
+//	    }
+//	  })).
+//	  WithRequestFilters(authFilter))
+func NewWebSocketController(path string, handler WebSocketHandler) WebSocketControllerSpec {
+	options := &webSocketOptions{handler: handler}
+
+	return &webSocketControllerSpec{
+		controllerSpec: &controllerSpec{
+			path:    path,
+			methods: []string{http.MethodGet},
+			handler: webSocketUpgradeHandler{options},
+			headers: make(map[string]string),
+		},
+		options: options,
+	}
+}
+
+type webSocketControllerSpec struct {
+	*controllerSpec
+	options *webSocketOptions
+}
+
+func (w *webSocketControllerSpec) WithSubprotocols(protocols ...string) WebSocketControllerSpec {
+	w.options.protocols = append(w.options.protocols, protocols...)
+	return w
+}
+
+func (w *webSocketControllerSpec) WithAllowedOrigins(origins ...string) WebSocketControllerSpec {
+	w.options.origins = append(w.options.origins, origins...)
+	return w
+}
+
+func (w *webSocketControllerSpec) WithMaxMessageSize(bytes int64) WebSocketControllerSpec {
+	w.options.maxMessage = bytes
+	return w
+}
+
+type webSocketOptions struct {
+	handler    WebSocketHandler
+	protocols  []string
+	origins    []string
+	maxMessage int64
+}
+
+// allowsOrigin tests whether a WebSocket connection may be opened by a
+// browser from the given request's origin.
+func (w *webSocketOptions) allowsOrigin(r *http.Request) bool {
+	origin := r.Header.Get(HeaderOrigin)
+	if len(origin) == 0 {
+		return true
+	}
+
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range w.origins {
+		if allowed == "*" || matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subprotocol returns the first of the controller's subprotocols requested by
+// the given request, or an empty string if none were requested.
+func (w *webSocketOptions) subprotocol(r *http.Request) string {
+	requested := headerTokens(r.Header, HeaderSecWebSocketProtocol)
+
+	for _, protocol := range w.protocols {
+		for _, token := range requested {
+			if token == protocol {
+				return protocol
+			}
+		}
+	}
+
+	return ""
+}
+
+func (w *webSocketOptions) maxMessageSize() int64 {
+	switch {
+	case w.maxMessage > 0:
+		return w.maxMessage
+	case w.maxMessage < 0:
+		return 0
+	default:
+		return DefaultWebSocketMaxMessageSize
+	}
+}
+
+// webSocketUpgradeHandler is the RequestHandler for controllers built from
+// NewWebSocketController.
+//
+// The handshake itself is validated and performed by the controller once the
+// Response has been returned, see controller.checkWebSocketHandshake.
+type webSocketUpgradeHandler struct {
+	options *webSocketOptions
+}
+
+func (w webSocketUpgradeHandler) HandleRequest(Request) Response {
+	return NewResponse().WithBody(&webSocketUpgrade{options: w.options})
+}
+
+// webSocketUpgrade is the body of a Response that accepts a WebSocket
+// connection.
+type webSocketUpgrade struct {
+	options     *webSocketOptions
+	subprotocol string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// checkWebSocketHandshake validates the WebSocket handshake request if the
+// given response accepts a WebSocket connection, returning the response with
+// the handshake headers set, or an error response if the request is not a valid
+// handshake request.
+func (c controller) checkWebSocketHandshake(request Request, response Response) Response {
+	upgrade, ok := response.GetBody().(*webSocketUpgrade)
+	if !ok {
+		return response
+	}
+
+	raw := request.Raw()
+
+	if !headerHasToken(raw.Header, HeaderConnection, "upgrade") || !headerHasToken(raw.Header, HeaderUpgrade, "websocket") {
+		c.logger.Debug("request is not a websocket handshake, returning 426 error")
+		return c.errHandlers.errorResponse(http.StatusUpgradeRequired, "a WebSocket handshake is required").
+			WithHeader(HeaderUpgrade, "websocket").
+			WithHeader(HeaderConnection, "Upgrade")
+	}
+
+	if raw.Method != http.MethodGet || raw.ProtoMajor != 1 {
+		return c.errHandlers.errorResponse(http.StatusBadRequest, "WebSocket handshakes must be HTTP/1.1 GET requests")
+	}
+
+	if raw.Header.Get(HeaderSecWebSocketVersion) != "13" {
+		return c.errHandlers.errorResponse(http.StatusUpgradeRequired, "unsupported WebSocket version").
+			WithHeader(HeaderSecWebSocketVersion, "13")
+	}
+
+	key := raw.Header.Get(HeaderSecWebSocketKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return c.errHandlers.errorResponse(http.StatusBadRequest, "invalid Sec-WebSocket-Key header")
+	}
+
+	if !upgrade.options.allowsOrigin(raw) {
+		c.logger.Debug("websocket origin not allowed, returning 403 error", "origin", raw.Header.Get(HeaderOrigin))
+		return c.errHandlers.errorResponse(http.StatusForbidden, "WebSocket connections are not allowed from this origin")
+	}
+
+	response.WithCode(http.StatusSwitchingProtocols).
+		WithHeader(HeaderUpgrade, "websocket").
+		WithHeader(HeaderConnection, "Upgrade").
+		WithHeader(HeaderSecWebSocketAccept, webSocketAcceptKey(key))
+
+	if upgrade.subprotocol = upgrade.options.subprotocol(raw); len(upgrade.subprotocol) > 0 {
+		response.WithHeader(HeaderSecWebSocketProtocol, upgrade.subprotocol)
+	}
+
+	return response
+}
+
+// serveWebSocket completes the WebSocket handshake by sending the response
+// headers and taking over the connection, then calls the WebSocketHandler.
+func (c controller) serveWebSocket(writer http.ResponseWriter, request Request, upgrade *webSocketUpgrade) {
+	writer.WriteHeader(http.StatusSwitchingProtocols)
+
+	netConn, buffered, err := http.NewResponseController(writer).Hijack()
+	if err != nil {
+		c.logger.Error("failed to take over connection for websocket", "error", err)
+		return
+	}
+
+	conn := newWebSocketConn(netConn, buffered.Reader, upgrade.subprotocol, upgrade.options.maxMessageSize())
+
+	defer func() {
+		if err := netConn.Close(); err != nil {
+			c.logger.Debug("failed to close websocket connection", "error", err)
+		}
+	}()
+
+	c.logger.Debug("accepted websocket connection", "subprotocol", upgrade.subprotocol)
+
+	if err = upgrade.options.handler.HandleWebSocket(request, conn); err != nil {
+		c.logger.Error("websocket handler failed", "error", err)
+		_ = conn.Close(WebSocketCloseInternalError, "")
+		return
+	}
+
+	_ = conn.Close(WebSocketCloseNormal, "")
+}
+
+// webSocketAcceptKey computes the Sec-WebSocket-Accept header value for the
+// given Sec-WebSocket-Key.
+func webSocketAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// headerTokens returns the comma separated tokens of every value of the given
+// header.
+func headerTokens(header http.Header, name string) []string {
+	var out []string
+
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); len(token) > 0 {
+				out = append(out, token)
+			}
+		}
+	}
+
+	return out
+}
+
+// headerHasToken tests whether any value of the given header contains the
+// given comma separated token, ignoring case.
+func headerHasToken(header http.Header, name, token string) bool {
+	return containsFold(headerTokens(header, name), token)
+}
@@ -0,0 +1,231 @@
+package swrv_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+// sampleWebSocketKey and sampleWebSocketAccept are the example handshake key
+// and accept values given in RFC 6455 section 1.3.
+const (
+	sampleWebSocketKey    = "dGhlIHNhbXBsZSBub25jZQ=="
+	sampleWebSocketAccept = "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+)
+
+func newEchoServer() swrv.Server {
+	return swrv.NewServer("", 0).
+		WithControllers(swrv.NewWebSocketController("/echo",
+			swrv.WebSocketHandlerFunc(func(_ swrv.Request, conn swrv.WebSocketConn) error {
+				for {
+					kind, message, err := conn.ReadMessage()
+					if err != nil {
+						return nil
+					}
+
+					if err = conn.WriteMessage(kind, message); err != nil {
+						return err
+					}
+				}
+			})).
+			WithSubprotocols("echo.v1"))
+}
+
+// webSocketClient is a minimal client side WebSocket connection for tests.
+type webSocketClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket performs the WebSocket handshake with the given test server,
+// returning the handshake response and the connection.
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) (*http.Response, *webSocketClient) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	request.Header.Set(swrv.HeaderConnection, "Upgrade")
+	request.Header.Set(swrv.HeaderUpgrade, "websocket")
+	request.Header.Set(swrv.HeaderSecWebSocketVersion, "13")
+	request.Header.Set(swrv.HeaderSecWebSocketKey, sampleWebSocketKey)
+	request.Header.Set(swrv.HeaderSecWebSocketProtocol, "other, echo.v1")
+
+	if err = request.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response, &webSocketClient{conn: conn, reader: reader}
+}
+
+// writeFrame writes a single masked frame with the given opcode and payload.
+func (w *webSocketClient) writeFrame(t *testing.T, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+
+	frame := []byte{first}
+
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	if _, err := w.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFrame reads a single unmasked frame, returning its opcode and payload.
+func (w *webSocketClient) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(w.reader, header); err != nil {
+		t.Fatal(err)
+	}
+
+	if header[1]&0x80 != 0 {
+		t.Fatal("server frames must not be masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(w.reader, extended); err != nil {
+			t.Fatal(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(w.reader, extended); err != nil {
+			t.Fatal(err)
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(w.reader, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	server := httptest.NewServer(newEchoServer().Handler())
+	defer server.Close()
+
+	response, _ := dialWebSocket(t, server, "/echo")
+
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status 101, got %d", response.StatusCode)
+	}
+
+	if accept := response.Header.Get(swrv.HeaderSecWebSocketAccept); accept != sampleWebSocketAccept {
+		t.Errorf("expected accept key %q, got %q", sampleWebSocketAccept, accept)
+	}
+
+	if protocol := response.Header.Get(swrv.HeaderSecWebSocketProtocol); protocol != "echo.v1" {
+		t.Errorf("expected subprotocol %q, got %q", "echo.v1", protocol)
+	}
+}
+
+func TestWebSocketHandshakeRequired(t *testing.T) {
+	swrvtest.New(newEchoServer()).GET("/echo").Expect(t).
+		Status(http.StatusUpgradeRequired).
+		Header(swrv.HeaderUpgrade, "websocket")
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	server := httptest.NewServer(newEchoServer().Handler())
+	defer server.Close()
+
+	_, client := dialWebSocket(t, server, "/echo")
+
+	// A single frame text message.
+	client.writeFrame(t, true, 0x1, []byte("hello"))
+
+	if opcode, payload := client.readFrame(t); opcode != 0x1 || string(payload) != "hello" {
+		t.Errorf("expected text frame %q, got opcode %d payload %q", "hello", opcode, payload)
+	}
+
+	// A fragmented binary message with an interleaved ping, using an extended
+	// payload length.
+	large := []byte(strings.Repeat("x", 300))
+
+	client.writeFrame(t, false, 0x2, large[:100])
+	client.writeFrame(t, true, 0x9, []byte("ping"))
+	client.writeFrame(t, true, 0x0, large[100:])
+
+	if opcode, payload := client.readFrame(t); opcode != 0xA || string(payload) != "ping" {
+		t.Errorf("expected pong frame %q, got opcode %d payload %q", "ping", opcode, payload)
+	}
+
+	if opcode, payload := client.readFrame(t); opcode != 0x2 || string(payload) != string(large) {
+		t.Errorf("expected %d byte binary frame, got opcode %d with %d bytes", len(large), opcode, len(payload))
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	server := httptest.NewServer(newEchoServer().Handler())
+	defer server.Close()
+
+	_, client := dialWebSocket(t, server, "/echo")
+
+	client.writeFrame(t, true, 0x8, append(binary.BigEndian.AppendUint16(nil, swrv.WebSocketCloseNormal), "bye"...))
+
+	opcode, payload := client.readFrame(t)
+	if opcode != 0x8 {
+		t.Fatalf("expected close frame, got opcode %d", opcode)
+	}
+
+	if len(payload) < 2 || binary.BigEndian.Uint16(payload) != swrv.WebSocketCloseNormal {
+		t.Errorf("expected close code %d, got payload %v", swrv.WebSocketCloseNormal, payload)
+	}
+
+	// The server closes the connection once the close handshake has completed.
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package swrvgorilla provides a swrv.Router adapter for the
+// github.com/gorilla/mux router.
+//
+// Example:
+//
+//	server.Start(swrvgorilla.NewRouter())
+package swrvgorilla
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/gorilla/mux"
+)
+
+// NewRouter returns a new swrv.Router backed by a new gorilla mux.Router.
+func NewRouter() swrv.Router {
+	return Wrap(mux.NewRouter())
+}
+
+// Wrap returns a swrv.Router backed by the given gorilla mux.Router.
+//
+// Route paths use the gorilla path template syntax, for example "/users/{id}"
+// or "/users/{id:[0-9]+}".  A final "{name...}" catch-all wildcard, as used by
+// swrv.NewStaticController, is also supported, and matches the remainder of
+// the request path as it would with the built-in swrv Routers.
+//
+// If the given router does not have a MethodNotAllowedHandler set, one will be
+// set that responds with a plain 405 error.  In either case, 405 responses will
+// include an Allow header listing the methods registered for the request path.
+func Wrap(router *mux.Router) swrv.Router {
+	out := gorillaRouter{router: router, root: router}
+
+	if router.MethodNotAllowedHandler == nil {
+		out.MethodNotAllowedHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}))
+	} else {
+		out.MethodNotAllowedHandler(router.MethodNotAllowedHandler)
+	}
+
+	return out
+}
+
+type gorillaRouter struct {
+	router *mux.Router
+	root   *mux.Router
+}
+
+func (g gorillaRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.router.ServeHTTP(w, r)
+}
+
+func (g gorillaRouter) Handle(route swrv.Route, handler http.Handler) error {
+	r := g.router.Path(catchAllTemplate(route.Path))
+
+	// If the controller should only fire for specific HTTP methods
+	if len(route.Methods) > 0 {
+		r.Methods(route.Methods...)
+	}
+
+	// If the controller requires specific headers to be set.
+	if len(route.Headers) > 0 {
+		pairs := make([]string, 0, len(route.Headers)*2)
+
+		for head, match := range route.Headers {
+			pairs = append(pairs, head, match)
+		}
+
+		r.Headers(pairs...)
+	}
+
+	return r.Handler(handler).GetError()
+}
+
+func (g gorillaRouter) Group(prefix string) swrv.Router {
+	return gorillaRouter{router: g.router.PathPrefix(prefix).Subrouter(), root: g.root}
+}
+
+func (g gorillaRouter) NotFoundHandler(handler http.Handler) {
+	g.router.NotFoundHandler = handler
+}
+
+func (g gorillaRouter) MethodNotAllowedHandler(handler http.Handler) {
+	g.router.MethodNotAllowedHandler = allowHandler{root: g.root, handler: handler}
+}
+
+func (g gorillaRouter) PathParam(request *http.Request, name string) string {
+	return mux.Vars(request)[name]
+}
+
+func (g gorillaRouter) PathParams(request *http.Request) map[string]string {
+	return mux.Vars(request)
+}
+
+// catchAllTemplate translates a final "{name...}" catch-all wildcard in the
+// given route path into the equivalent gorilla regular expression template,
+// "{name:.*}".
+//
+// Without this, gorilla would treat "name..." as the name of a single segment
+// variable.
+func catchAllTemplate(path string) string {
+	start := strings.LastIndexByte(path, '{')
+	if start < 0 || !strings.HasSuffix(path, "...}") || strings.IndexByte(path[start:], ':') >= 0 {
+		return path
+	}
+
+	return path[:start] + "{" + path[start+1:len(path)-4] + ":.*}"
+}
+
+// allowHandler sets the Allow header on 405 responses, as gorilla does not.
+type allowHandler struct {
+	root    *mux.Router
+	handler http.Handler
+}
+
+func (a allowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if allowed := allowedMethods(a.root, r); len(allowed) > 0 {
+		w.Header().Set(swrv.HeaderAllow, strings.Join(allowed, ", "))
+	}
+
+	a.handler.ServeHTTP(w, r)
+}
+
+// allowedMethods returns the methods of the routes registered with the given
+// router that would match the given request if it used that method.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var out []string
+
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if slices.Contains(out, method) {
+				continue
+			}
+
+			probe := r.Clone(r.Context())
+			probe.Method = method
+
+			var match mux.RouteMatch
+			if route.Match(probe, &match) && match.MatchErr == nil {
+				out = append(out, method)
+			}
+		}
+
+		return nil
+	})
+
+	return out
+}
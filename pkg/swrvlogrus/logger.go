@@ -0,0 +1,86 @@
+// Package swrvlogrus provides a swrv.Logger adapter for the
+// github.com/sirupsen/logrus logging library.
+//
+// Example:
+//
+//	server.WithLogger(swrvlogrus.New(logrus.StandardLogger()))
+package swrvlogrus
+
+import (
+	"log/slog"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/sirupsen/logrus"
+)
+
+// New returns a swrv.Logger that logs through the given logrus Logger.
+func New(logger *logrus.Logger) swrv.Logger {
+	return logrusLogger{logrus.NewEntry(logger)}
+}
+
+// NewFromEntry returns a swrv.Logger that logs through the given logrus Entry,
+// including the Entry's fields with every message.
+func NewFromEntry(entry *logrus.Entry) swrv.Logger {
+	return logrusLogger{entry}
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+func (l logrusLogger) Debug(msg string, args ...any) {
+	l.withArgs(args).Debug(msg)
+}
+
+func (l logrusLogger) Info(msg string, args ...any) {
+	l.withArgs(args).Info(msg)
+}
+
+func (l logrusLogger) Warn(msg string, args ...any) {
+	l.withArgs(args).Warn(msg)
+}
+
+func (l logrusLogger) Error(msg string, args ...any) {
+	l.withArgs(args).Error(msg)
+}
+
+func (l logrusLogger) With(args ...any) swrv.Logger {
+	return logrusLogger{l.withArgs(args)}
+}
+
+func (l logrusLogger) withArgs(args []any) *logrus.Entry {
+	if len(args) == 0 {
+		return l.entry
+	}
+
+	return l.entry.WithFields(toFields(args))
+}
+
+// toFields converts the given alternating key/value pairs into logrus Fields.
+//
+// As with log/slog, slog.Attr values are accepted in place of a key/value pair,
+// and a value without a string key is recorded under the key "!BADKEY".
+func toFields(args []any) logrus.Fields {
+	fields := make(logrus.Fields, (len(args)+1)/2)
+
+	for len(args) > 0 {
+		if attr, ok := args[0].(slog.Attr); ok {
+			fields[attr.Key] = attr.Value.Any()
+			args = args[1:]
+			continue
+		}
+
+		key, ok := args[0].(string)
+
+		if !ok || len(args) == 1 {
+			fields["!BADKEY"] = args[0]
+			args = args[1:]
+			continue
+		}
+
+		fields[key] = args[1]
+		args = args[2:]
+	}
+
+	return fields
+}
@@ -0,0 +1,93 @@
+// Package swrvtest provides an in-memory test harness for exercising swrv
+// Server instances without binding to a network port.
+//
+// Example:
+//
+//	var body Response
+//
+//	swrvtest.New(server).
+//		GET("/hello/world").
+//		WithHeader(swrv.HeaderAccept, swrv.ContentTypeApplicationJSON).
+//		Expect(t).
+//		Status(200).
+//		JSONBody(&body)
+package swrvtest
+
+import (
+	"net/http"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+// New returns a new Client that sends requests to the handler built from the
+// given Server.
+//
+// Building the handler freezes the Server's configuration, the same as
+// starting it would.
+func New(server swrv.Server) *Client {
+	return NewFromHandler(server.Handler())
+}
+
+// NewFromHandler returns a new Client that sends requests to the given
+// http.Handler.
+func NewFromHandler(handler http.Handler) *Client {
+	return &Client{handler: handler, headers: make(http.Header)}
+}
+
+// Client builds requests that are sent directly to an http.Handler and
+// recorded in memory.
+type Client struct {
+	handler http.Handler
+	headers http.Header
+}
+
+// WithHeader sets a default header that will be sent with every request built
+// by this Client.
+//
+// Headers set on an individual request replace the default values.
+func (c *Client) WithHeader(header, value string) *Client {
+	c.headers.Add(header, value)
+	return c
+}
+
+// Request begins building a new request with the given method and path.
+//
+// The path may include a query string.
+func (c *Client) Request(method, path string) *Request {
+	return newRequest(c, method, path)
+}
+
+// GET begins building a new GET request to the given path.
+func (c *Client) GET(path string) *Request {
+	return c.Request(http.MethodGet, path)
+}
+
+// HEAD begins building a new HEAD request to the given path.
+func (c *Client) HEAD(path string) *Request {
+	return c.Request(http.MethodHead, path)
+}
+
+// POST begins building a new POST request to the given path.
+func (c *Client) POST(path string) *Request {
+	return c.Request(http.MethodPost, path)
+}
+
+// PUT begins building a new PUT request to the given path.
+func (c *Client) PUT(path string) *Request {
+	return c.Request(http.MethodPut, path)
+}
+
+// PATCH begins building a new PATCH request to the given path.
+func (c *Client) PATCH(path string) *Request {
+	return c.Request(http.MethodPatch, path)
+}
+
+// DELETE begins building a new DELETE request to the given path.
+func (c *Client) DELETE(path string) *Request {
+	return c.Request(http.MethodDelete, path)
+}
+
+// OPTIONS begins building a new OPTIONS request to the given path.
+func (c *Client) OPTIONS(path string) *Request {
+	return c.Request(http.MethodOptions, path)
+}
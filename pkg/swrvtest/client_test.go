@@ -0,0 +1,141 @@
+package swrvtest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+	"github.com/foxcapades/swrv/pkg/swrvtest"
+)
+
+// recordingTB is a testing.TB that records reported failures rather than
+// failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// echoHandler responds with a JSON description of the request it received.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	w.Header().Set(swrv.HeaderContentType, swrv.ContentTypeApplicationJSON)
+	w.Header().Set("X-Method", r.Method)
+	w.WriteHeader(http.StatusAccepted)
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"path":        r.URL.Path,
+		"query":       r.URL.Query(),
+		"token":       r.Header.Values("X-Token"),
+		"contentType": r.Header.Get(swrv.HeaderContentType),
+		"body":        string(body),
+	})
+})
+
+type echoed struct {
+	Path        string              `json:"path"`
+	Query       map[string][]string `json:"query"`
+	Token       []string            `json:"token"`
+	ContentType string              `json:"contentType"`
+	Body        string              `json:"body"`
+}
+
+func TestRequestBuilding(t *testing.T) {
+	client := swrvtest.NewFromHandler(echoHandler).WithHeader("X-Token", "default")
+
+	var out echoed
+
+	client.POST("/things?a=1").
+		WithQueryParam("b", "2").
+		WithJSONBody(map[string]int{"n": 1}).
+		Expect(t).
+		Status(http.StatusAccepted).
+		Header("X-Method", http.MethodPost).
+		JSONBody(&out)
+
+	if out.Path != "/things" {
+		t.Errorf("expected path /things, got %q", out.Path)
+	}
+
+	if out.Query["a"][0] != "1" || out.Query["b"][0] != "2" {
+		t.Errorf("expected query parameters a=1 and b=2, got %v", out.Query)
+	}
+
+	if len(out.Token) != 1 || out.Token[0] != "default" {
+		t.Errorf("expected the client's default header, got %v", out.Token)
+	}
+
+	if out.ContentType != swrv.ContentTypeApplicationJSON || out.Body != "{\"n\":1}\n" {
+		t.Errorf("expected a JSON body, got %q with content type %q", out.Body, out.ContentType)
+	}
+
+	client.GET("/things").
+		WithHeader("X-Token", "override").
+		Expect(t).
+		JSONBody(&out)
+
+	if len(out.Token) != 1 || out.Token[0] != "override" {
+		t.Errorf("expected the request header to replace the default, got %v", out.Token)
+	}
+}
+
+func TestExpectationFailures(t *testing.T) {
+	recorder := &recordingTB{TB: t}
+
+	swrvtest.NewFromHandler(echoHandler).
+		GET("/things").
+		Expect(recorder).
+		Status(http.StatusAccepted).
+		Header("X-Method", http.MethodGet).
+		HasHeader(swrv.HeaderContentType).
+		BodyContains("/things")
+
+	if len(recorder.errors) > 0 {
+		t.Fatalf("expected passing assertions to report nothing, got %q", recorder.errors)
+	}
+
+	swrvtest.NewFromHandler(echoHandler).
+		GET("/things").
+		Expect(recorder).
+		Status(http.StatusOK).
+		Header("X-Method", http.MethodPut).
+		NoHeader("X-Method").
+		HasHeader("X-Missing").
+		Body("nope").
+		BodyContains("nope").
+		JSONBody(new(int))
+
+	if len(recorder.errors) != 7 {
+		t.Errorf("expected each failing assertion to be reported, got %d: %q", len(recorder.errors), recorder.errors)
+	}
+}
+
+func TestNewFromServer(t *testing.T) {
+	server := swrv.NewServer("", 0).
+		WithObjectSerializers(swrv.NewDefaultJSONObjectSerializer()).
+		WithControllers(swrv.NewController("/hello/{name}", swrv.RequestHandlerFunc(func(request swrv.Request) swrv.Response {
+			return swrv.NewResponse().WithBody(map[string]string{"hello": request.URIParam("name")})
+		})))
+
+	var body map[string]string
+
+	swrvtest.New(server).
+		GET("/hello/world").
+		WithHeader(swrv.HeaderAccept, swrv.ContentTypeApplicationJSON).
+		Expect(t).
+		Status(http.StatusOK).
+		JSONBody(&body)
+
+	if body["hello"] != "world" {
+		t.Errorf("expected hello world, got %v", body)
+	}
+}
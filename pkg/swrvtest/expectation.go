@@ -0,0 +1,106 @@
+package swrvtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Expectation makes assertions about a recorded response.
+//
+// Failed assertions are reported using testing.TB.Errorf, and do not stop the
+// test.
+type Expectation struct {
+	t        testing.TB
+	recorder *httptest.ResponseRecorder
+}
+
+// Status asserts that the response status code equals the given code.
+func (e *Expectation) Status(code int) *Expectation {
+	e.t.Helper()
+
+	if e.recorder.Code != code {
+		e.t.Errorf("expected response status %d, got %d; body: %s", code, e.recorder.Code, e.recorder.Body.String())
+	}
+
+	return e
+}
+
+// Header asserts that the first value of the given response header equals the
+// given value.
+func (e *Expectation) Header(header, value string) *Expectation {
+	e.t.Helper()
+
+	if actual := e.recorder.Header().Get(header); actual != value {
+		e.t.Errorf("expected response header %s to be %q, got %q", header, value, actual)
+	}
+
+	return e
+}
+
+// HasHeader asserts that the response contains the given header.
+func (e *Expectation) HasHeader(header string) *Expectation {
+	e.t.Helper()
+
+	if len(e.recorder.Header().Values(header)) == 0 {
+		e.t.Errorf("expected response header %s to be set", header)
+	}
+
+	return e
+}
+
+// NoHeader asserts that the response does not contain the given header.
+func (e *Expectation) NoHeader(header string) *Expectation {
+	e.t.Helper()
+
+	if values := e.recorder.Header().Values(header); len(values) > 0 {
+		e.t.Errorf("expected response header %s to be unset, got %q", header, values)
+	}
+
+	return e
+}
+
+// Body asserts that the response body equals the given string.
+func (e *Expectation) Body(body string) *Expectation {
+	e.t.Helper()
+
+	if actual := e.recorder.Body.String(); actual != body {
+		e.t.Errorf("expected response body %q, got %q", body, actual)
+	}
+
+	return e
+}
+
+// BodyContains asserts that the response body contains the given string.
+func (e *Expectation) BodyContains(substring string) *Expectation {
+	e.t.Helper()
+
+	if actual := e.recorder.Body.String(); !strings.Contains(actual, substring) {
+		e.t.Errorf("expected response body to contain %q, got %q", substring, actual)
+	}
+
+	return e
+}
+
+// JSONBody decodes the response body as JSON into the given target value.
+func (e *Expectation) JSONBody(target any) *Expectation {
+	e.t.Helper()
+
+	if err := json.Unmarshal(e.recorder.Body.Bytes(), target); err != nil {
+		e.t.Errorf("failed to decode response body as JSON: %s; body: %s", err, e.recorder.Body.String())
+	}
+
+	return e
+}
+
+// Recorder returns the underlying httptest.ResponseRecorder.
+func (e *Expectation) Recorder() *httptest.ResponseRecorder {
+	return e.recorder
+}
+
+// Response returns the recorded response as an http.Response.
+func (e *Expectation) Response() *http.Response {
+	return e.recorder.Result()
+}
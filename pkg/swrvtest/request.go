@@ -0,0 +1,142 @@
+package swrvtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/foxcapades/swrv/pkg/swrv"
+)
+
+func newRequest(client *Client, method, path string) *Request {
+	return &Request{
+		client:  client,
+		method:  method,
+		path:    path,
+		headers: client.headers.Clone(),
+		query:   make(url.Values),
+	}
+}
+
+// Request is a builder for a single test request.
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	headers http.Header
+	query   url.Values
+	body    io.Reader
+	ctx     context.Context
+	err     error
+}
+
+// WithHeader sets the given header on the request, replacing any default value
+// set on the parent Client.
+func (r *Request) WithHeader(header, value string) *Request {
+	r.headers.Set(header, value)
+	return r
+}
+
+// WithQueryParam appends the given query parameter to the request URL.
+func (r *Request) WithQueryParam(name, value string) *Request {
+	r.query.Add(name, value)
+	return r
+}
+
+// WithContext sets the context.Context the request will be sent with.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// WithBody sets the raw request body.
+func (r *Request) WithBody(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// WithStringBody sets the raw request body to the given string.
+func (r *Request) WithStringBody(body string) *Request {
+	return r.WithBody(strings.NewReader(body))
+}
+
+// WithJSONBody sets the request body to the JSON encoding of the given value,
+// and sets the request Content-Type to "application/json" if it has not
+// already been set.
+//
+// If the value cannot be encoded, the error will be reported when the request
+// is sent.
+func (r *Request) WithJSONBody(value any) *Request {
+	buffer := new(bytes.Buffer)
+
+	if err := json.NewEncoder(buffer).Encode(value); err != nil {
+		r.err = err
+	}
+
+	if len(r.headers.Get(swrv.HeaderContentType)) == 0 {
+		r.headers.Set(swrv.HeaderContentType, swrv.ContentTypeApplicationJSON)
+	}
+
+	return r.WithBody(buffer)
+}
+
+// Build returns the http.Request that will be sent to the handler.
+func (r *Request) Build() (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	target := r.path
+
+	if len(r.query) > 0 {
+		if strings.Contains(target, "?") {
+			target += "&" + r.query.Encode()
+		} else {
+			target += "?" + r.query.Encode()
+		}
+	}
+
+	req := httptest.NewRequest(r.method, target, r.body)
+	req.Header = r.headers.Clone()
+
+	if r.ctx != nil {
+		req = req.WithContext(r.ctx)
+	}
+
+	return req, nil
+}
+
+// Do sends the request to the Client's handler and returns the recorded
+// response.
+func (r *Request) Do() (*httptest.ResponseRecorder, error) {
+	req, err := r.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(recorder, req)
+
+	return recorder, nil
+}
+
+// Expect sends the request to the Client's handler and returns an Expectation
+// that may be used to make assertions about the response.
+//
+// If the request could not be built, the test will be failed immediately.
+func (r *Request) Expect(t testing.TB) *Expectation {
+	t.Helper()
+
+	recorder, err := r.Do()
+	if err != nil {
+		t.Fatalf("failed to build %s request to %s: %s", r.method, r.path, err)
+	}
+
+	return &Expectation{t: t, recorder: recorder}
+}